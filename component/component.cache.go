@@ -21,6 +21,7 @@ type IComponentCache interface {
 	GetCache(names ...string) (c cache.ICache, err error)
 	GetCacheBy(tpName string, name string) (c cache.ICache, err error)
 	SaveCacheObject(tpName string, name string, f func(c conf.IConf) (cache.ICache, error)) (bool, cache.ICache, error)
+	ReleaseCacheObjects() []string
 	Close() error
 }
 
@@ -97,3 +98,10 @@ func (s *StandardCache) Close() error {
 	})
 	return nil
 }
+
+//ReleaseCacheObjects 移除var配置已变化的缓存对象,原对象延迟关闭
+func (s *StandardCache) ReleaseCacheObjects() []string {
+	return releaseObjects(s.IContainer, s.cacheMap, func(v interface{}) {
+		v.(cache.ICache).Close()
+	})
+}
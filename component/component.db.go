@@ -22,6 +22,7 @@ type IComponentDB interface {
 	GetDB(names ...string) (d db.IDB, err error)
 	GetDBBy(tpName string, name string) (c db.IDB, err error)
 	SaveDBObject(tpName string, name string, f func(c conf.IConf) (db.IDB, error)) (bool, db.IDB, error)
+	ReleaseDBObjects() []string
 	Close() error
 }
 
@@ -102,3 +103,10 @@ func (s *StandardDB) Close() error {
 	})
 	return nil
 }
+
+//ReleaseDBObjects 移除var配置已变化的数据库对象,原对象延迟关闭
+func (s *StandardDB) ReleaseDBObjects() []string {
+	return releaseObjects(s.IContainer, s.dbMap, func(v interface{}) {
+		v.(*db.DB).Close()
+	})
+}
@@ -0,0 +1,48 @@
+package component
+
+//HealthCheck 就绪检查项
+type HealthCheck struct {
+	Name  string
+	Check ComponentFunc
+}
+
+//healthKey 缓存与队列检查使用的key
+const healthKey = "__parrot_health_"
+
+//CheckDB 检查数据库连接,sql为空时执行select 1
+func CheckDB(name string, sql string) ComponentFunc {
+	if sql == "" {
+		sql = "select 1"
+	}
+	return func(c IContainer) error {
+		db, err := c.GetDB(name)
+		if err != nil {
+			return err
+		}
+		_, _, _, err = db.Scalar(sql, nil)
+		return err
+	}
+}
+
+//CheckCache 检查缓存连接
+func CheckCache(name string) ComponentFunc {
+	return func(c IContainer) error {
+		cache, err := c.GetCache(name)
+		if err != nil {
+			return err
+		}
+		return cache.Set(healthKey, "1", 60)
+	}
+}
+
+//CheckQueue 检查消息队列连接
+func CheckQueue(name string) ComponentFunc {
+	return func(c IContainer) error {
+		queue, err := c.GetQueue(name)
+		if err != nil {
+			return err
+		}
+		_, err = queue.Count(healthKey)
+		return err
+	}
+}
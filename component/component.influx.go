@@ -24,6 +24,7 @@ type IComponentInfluxDB interface {
 	GetInflux(names ...string) (d influxdb.IInfluxClient, err error)
 	GetInfluxBy(tpName string, name string) (c influxdb.IInfluxClient, err error)
 	SaveInfluxObject(tpName string, name string, f func(c conf.IConf) (influxdb.IInfluxClient, error)) (bool, influxdb.IInfluxClient, error)
+	ReleaseInfluxObjects() []string
 	Close() error
 }
 
@@ -101,3 +102,10 @@ func (s *StandardInfluxDB) Close() error {
 	})
 	return nil
 }
+
+//ReleaseInfluxObjects 移除var配置已变化的influxdb对象,原对象延迟关闭
+func (s *StandardInfluxDB) ReleaseInfluxObjects() []string {
+	return releaseObjects(s.IContainer, s.influxdbCache, func(v interface{}) {
+		v.(*influxdb.InfluxClient).Close()
+	})
+}
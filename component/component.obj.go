@@ -14,6 +14,7 @@ var _ IComponentGlobalVarObject = &GlobalVarObjectCache{}
 type IComponentGlobalVarObject interface {
 	GetGlobalObject(tpName string, name string) (c interface{}, err error)
 	SaveGlobalObject(tpName string, name string, f func(c conf.IConf) (interface{}, error)) (bool, interface{}, error)
+	ReleaseGlobalObjects() []string
 	Close() error
 }
 
@@ -77,3 +78,8 @@ func (s *GlobalVarObjectCache) Close() error {
 	}
 	return nil
 }
+
+//ReleaseGlobalObjects 移除var配置已变化的全局对象,下次获取时重新创建,原对象在关闭服务器时关闭
+func (s *GlobalVarObjectCache) ReleaseGlobalObjects() []string {
+	return releaseObjects(s.IContainer, s.cacheMap, nil)
+}
@@ -21,6 +21,7 @@ type IComponentQueue interface {
 	GetQueue(names ...string) (q queue.IQueue, err error)
 	GetQueueBy(tpName string, name string) (c queue.IQueue, err error)
 	SaveQueueObject(tpName string, name string, f func(c conf.IConf) (queue.IQueue, error)) (bool, queue.IQueue, error)
+	ReleaseQueueObjects() []string
 	Close() error
 }
 
@@ -97,3 +98,10 @@ func (s *StandardQueue) Close() error {
 	})
 	return nil
 }
+
+//ReleaseQueueObjects 移除var配置已变化的消息队列对象,原对象延迟关闭
+func (s *StandardQueue) ReleaseQueueObjects() []string {
+	return releaseObjects(s.IContainer, s.queueCache, func(v interface{}) {
+		v.(queue.IQueue).Close()
+	})
+}
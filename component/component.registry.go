@@ -18,6 +18,7 @@ type IComponentRegistry interface {
 	GetHandleds() []ServiceFunc
 	GetInitializings() []ComponentFunc
 	GetClosings() []ComponentFunc
+	GetHealthChecks() []*HealthCheck
 	GetRPCTLS() map[string][]string
 	IServiceRegistry
 }
@@ -28,6 +29,7 @@ type IComponentHandler interface {
 	GetHandleds() []ServiceFunc
 	GetInitializings() []ComponentFunc
 	GetClosings() []ComponentFunc
+	GetHealthChecks() []*HealthCheck
 	GetTags(name string) []string
 	GetDynamicQueue() chan *conf.Queue
 	GetDynamicCron() chan *conf.Task
@@ -106,6 +108,9 @@ type IServiceRegistry interface {
 
 	//Closing 关闭组件
 	Closing(c func(IContainer) error)
+
+	//Health 添加就绪检查,检查失败时/readyz返回未就绪
+	Health(name string, c func(IContainer) error)
 	//Handling 每个请求的预处理函数
 	Handling(h func(c *context.Context) (rs interface{}))
 
@@ -131,6 +136,7 @@ type ServiceRegistry struct {
 	handledFuncs      []ServiceFunc
 	initializingFuncs []ComponentFunc
 	closingFuncs      []ComponentFunc
+	healthChecks      []*HealthCheck
 	exts              map[string]interface{}
 	tags              map[string][]string
 	tls               map[string][]string
@@ -146,6 +152,7 @@ func NewServiceRegistry() *ServiceRegistry {
 		handledFuncs:      make([]ServiceFunc, 0, 1),
 		initializingFuncs: make([]ComponentFunc, 0, 1),
 		closingFuncs:      make([]ComponentFunc, 0, 1),
+		healthChecks:      make([]*HealthCheck, 0, 1),
 		services:          make(map[string]map[string]interface{}),
 		exts:              make(map[string]interface{}),
 		tags:              make(map[string][]string),
@@ -417,6 +424,11 @@ func (s *ServiceRegistry) Closing(c func(c IContainer) error) {
 	s.closingFuncs = append(s.closingFuncs, c)
 }
 
+//Health 添加就绪检查
+func (s *ServiceRegistry) Health(name string, c func(c IContainer) error) {
+	s.healthChecks = append(s.healthChecks, &HealthCheck{Name: name, Check: c})
+}
+
 //GetDynamicQueue 获取动态队列
 func (s *ServiceRegistry) GetDynamicQueue() chan *conf.Queue {
 	return s.dynamicQueues
@@ -457,6 +469,9 @@ func (s *ServiceRegistry) GetInitializings() []ComponentFunc {
 func (s *ServiceRegistry) GetClosings() []ComponentFunc {
 	return s.closingFuncs
 }
+func (s *ServiceRegistry) GetHealthChecks() []*HealthCheck {
+	return s.healthChecks
+}
 
 //AddRPCTLS 添加RPC认证证书
 func (s *ServiceRegistry) AddRPCTLS(platName string, cert string, key string) error {
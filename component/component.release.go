@@ -0,0 +1,38 @@
+package component
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sereiner/library/concurrent/cmap"
+	"github.com/sereiner/parrot/conf"
+)
+
+//ReleaseDelay var配置变更后延迟关闭原对象的时间,等待正在执行的请求完成
+var ReleaseDelay = time.Second * 30
+
+//releaseObjects 移除var配置已变化或已删除的缓存对象,key格式为 类型/名称:签名,
+//close不为空时延迟关闭移除的对象,返回移除的节点名称
+func releaseObjects(c conf.IVarConf, m cmap.ConcurrentMap, close func(v interface{})) []string {
+	names := make([]string, 0, 1)
+	for _, key := range m.Keys() {
+		i := strings.LastIndex(key, ":")
+		j := strings.Index(key, "/")
+		if i < 0 || j < 0 || j > i {
+			continue
+		}
+		tpName, name, signature := key[:j], key[j+1:i], key[i+1:]
+		if cnf, err := c.GetVarConf(tpName, name); err == nil && cnf.GetSignature() == signature {
+			continue
+		}
+		v, ok := m.Pop(key)
+		if !ok {
+			continue
+		}
+		names = append(names, key[:i])
+		if close != nil {
+			time.AfterFunc(ReleaseDelay, func() { close(v) })
+		}
+	}
+	return names
+}
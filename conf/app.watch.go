@@ -0,0 +1,189 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+//appValue 某个服务器绑定的app配置
+type appValue struct {
+	serverType string
+	signature  string
+	value      interface{}
+}
+
+//AppWatcher app配置绑定,按服务器分别保存配置对象,app节点变化时替换配置对象并执行回调
+type AppWatcher struct {
+	typ         reflect.Type
+	serverTypes map[string]bool
+	zero        interface{}
+	values      map[string]*appValue
+	paths       []string
+	handlers    []func(old interface{}, new interface{})
+	lock        sync.RWMutex
+}
+
+var appWatchers = make([]*AppWatcher, 0, 1)
+var appWatcherLock sync.RWMutex
+
+//Watch 绑定app配置,v为配置对象或其指针(如AppConf{}或&AppConf{}),用于确定配置类型,
+//serverTypes为监控的服务器类型,未指定时监控所有服务器,
+//配置加载前Get返回v类型的零值指针
+func Watch(v interface{}, serverTypes ...string) *AppWatcher {
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		panic("conf.Watch:配置类型不能为nil")
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	w := &AppWatcher{
+		typ:         typ,
+		serverTypes: make(map[string]bool),
+		zero:        reflect.New(typ).Interface(),
+		values:      make(map[string]*appValue),
+	}
+	for _, tp := range serverTypes {
+		w.serverTypes[tp] = true
+	}
+	appWatcherLock.Lock()
+	defer appWatcherLock.Unlock()
+	appWatchers = append(appWatchers, w)
+	return w
+}
+
+//Get 获取当前配置,返回值为配置类型的指针(如*AppConf),返回的对象不可修改;
+//指定serverType时返回该类型服务器的配置,否则返回最先加载的服务器配置
+func (w *AppWatcher) Get(serverType ...string) interface{} {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	for _, path := range w.paths {
+		v := w.values[path]
+		if len(serverType) == 0 || v.serverType == serverType[0] {
+			return v.value
+		}
+	}
+	return w.zero
+}
+
+//OnChange 注册配置变更回调,old,new均为配置类型的指针,首次加载时old为零值
+func (w *AppWatcher) OnChange(f func(old interface{}, new interface{})) *AppWatcher {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.handlers = append(w.handlers, f)
+	return w
+}
+
+//Close 取消绑定,不再接收配置变更
+func (w *AppWatcher) Close() {
+	appWatcherLock.Lock()
+	defer appWatcherLock.Unlock()
+	for i, v := range appWatchers {
+		if v == w {
+			appWatchers = append(appWatchers[:i], appWatchers[i+1:]...)
+			return
+		}
+	}
+}
+
+//watched 是否监控指定类型的服务器
+func (w *AppWatcher) watched(serverType string) bool {
+	return len(w.serverTypes) == 0 || w.serverTypes[serverType]
+}
+
+//update 根据服务器配置更新该服务器的app配置,内容未变化时不更新,回调在释放锁后执行
+func (w *AppWatcher) update(c IServerConf) error {
+	if !w.watched(c.GetServerType()) {
+		return nil
+	}
+	signature := ""
+	app, err := c.GetSubConf("app")
+	if err != nil && err != ErrNoSetting {
+		return err
+	}
+	if app != nil {
+		signature = app.GetSignature()
+	}
+
+	path := c.GetMainConfPath()
+	w.lock.Lock()
+	current, ok := w.values[path]
+	if (ok && current.signature == signature) || (!ok && signature == "") {
+		w.lock.Unlock()
+		return nil
+	}
+	nv := reflect.New(w.typ).Interface()
+	if app != nil {
+		if err := app.Unmarshal(nv); err != nil {
+			w.lock.Unlock()
+			return fmt.Errorf("app配置绑定到%s失败:%v", w.typ, err)
+		}
+	}
+	old := w.zero
+	if ok {
+		old = current.value
+	} else {
+		w.paths = append(w.paths, path)
+	}
+	w.values[path] = &appValue{serverType: c.GetServerType(), signature: signature, value: nv}
+	handlers := make([]func(old interface{}, new interface{}), len(w.handlers))
+	copy(handlers, w.handlers)
+	w.lock.Unlock()
+
+	for _, f := range handlers {
+		f(old, nv)
+	}
+	return nil
+}
+
+//remove 删除服务器的app配置
+func (w *AppWatcher) remove(path string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.values[path]; !ok {
+		return
+	}
+	delete(w.values, path)
+	for i, p := range w.paths {
+		if p == path {
+			w.paths = append(w.paths[:i], w.paths[i+1:]...)
+			break
+		}
+	}
+}
+
+//IsAppWatched 指定类型服务器的app配置是否已绑定,已绑定时app配置变化无需重启服务器
+func IsAppWatched(serverType string) bool {
+	appWatcherLock.RLock()
+	defer appWatcherLock.RUnlock()
+	for _, w := range appWatchers {
+		if w.watched(serverType) {
+			return true
+		}
+	}
+	return false
+}
+
+//NotifyAppConf 服务器配置加载或变更后更新所有app配置绑定
+func NotifyAppConf(c IServerConf) error {
+	appWatcherLock.RLock()
+	watchers := make([]*AppWatcher, len(appWatchers))
+	copy(watchers, appWatchers)
+	appWatcherLock.RUnlock()
+	for _, w := range watchers {
+		if err := w.update(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//RemoveAppConf 服务器关闭后删除所有app配置绑定中该服务器的配置
+func RemoveAppConf(c IServerConf) {
+	appWatcherLock.RLock()
+	defer appWatcherLock.RUnlock()
+	for _, w := range appWatchers {
+		w.remove(c.GetMainConfPath())
+	}
+}
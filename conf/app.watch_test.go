@@ -0,0 +1,114 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+type testAppConf struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit"`
+}
+
+func TestAppWatcher(t *testing.T) {
+	rgst := mem.New("TestAppWatcher")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+	rgst.CreatePersistentNode("/test/order/api/t/conf/app", `{"name":"order","limit":10}`)
+
+	w := Watch(testAppConf{}, "api")
+	defer w.Close()
+	ut.Expect(t, w.Get().(*testAppConf).Name, "")
+	changed := 0
+	w.OnChange(func(old interface{}, new interface{}) {
+		changed++
+	})
+
+	data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+	c, err := NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, NotifyAppConf(c), nil)
+	ut.Expect(t, w.Get().(*testAppConf).Limit, 10)
+	ut.Expect(t, changed, 1)
+
+	//内容未变化不触发回调
+	ut.Expect(t, NotifyAppConf(c), nil)
+	ut.Expect(t, changed, 1)
+
+	_, v, _ := rgst.GetValue("/test/order/api/t/conf/app")
+	rgst.Update("/test/order/api/t/conf/app", `{"name":"order","limit":20}`, v)
+	c, _ = NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	old := w.Get().(*testAppConf)
+	ut.Expect(t, NotifyAppConf(c), nil)
+	ut.Expect(t, w.Get().(*testAppConf).Limit, 20)
+	ut.Expect(t, old.Limit, 10)
+	ut.Expect(t, changed, 2)
+
+	//其它服务器类型不更新
+	rgst.CreatePersistentNode("/test/order/cron/t/conf", `{}`)
+	rgst.CreatePersistentNode("/test/order/cron/t/conf/app", `{"limit":30}`)
+	data, version, _ = rgst.GetValue("/test/order/cron/t/conf")
+	c, _ = NewServerConf("/test/order/cron/t/conf", data, version, rgst)
+	ut.Expect(t, NotifyAppConf(c), nil)
+	ut.Expect(t, w.Get().(*testAppConf).Limit, 20)
+
+	//类型不匹配时保留当前配置
+	_, v, _ = rgst.GetValue("/test/order/api/t/conf/app")
+	rgst.Update("/test/order/api/t/conf/app", `{"limit":"x"}`, v)
+	data, version, _ = rgst.GetValue("/test/order/api/t/conf")
+	c, _ = NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Refute(t, NotifyAppConf(c), nil)
+	ut.Expect(t, w.Get().(*testAppConf).Limit, 20)
+}
+
+func TestAppWatcherServers(t *testing.T) {
+	rgst := mem.New("TestAppWatcherServers")
+	defer rgst.Close()
+	for _, p := range []string{"/test/order/api/a/conf", "/test/order/api/b/conf", "/test/order/rpc/a/conf"} {
+		rgst.CreatePersistentNode(p, `{}`)
+	}
+	rgst.CreatePersistentNode("/test/order/api/a/conf/app", `{"limit":10}`)
+	rgst.CreatePersistentNode("/test/order/api/b/conf/app", `{"limit":20}`)
+	rgst.CreatePersistentNode("/test/order/rpc/a/conf/app", `{"limit":30}`)
+
+	ut.Expect(t, IsAppWatched("api"), false)
+	w := Watch(&testAppConf{})
+	defer w.Close()
+	ut.Expect(t, IsAppWatched("api"), true)
+
+	//回调中可再次注册回调
+	changed := 0
+	w.OnChange(func(old interface{}, new interface{}) {
+		changed++
+		w.OnChange(func(old interface{}, new interface{}) {})
+	})
+	notify := func(path string) {
+		data, version, _ := rgst.GetValue(path)
+		c, err := NewServerConf(path, data, version, rgst)
+		ut.Expect(t, err, nil)
+		ut.Expect(t, NotifyAppConf(c), nil)
+	}
+	notify("/test/order/api/a/conf")
+	notify("/test/order/api/b/conf")
+	notify("/test/order/rpc/a/conf")
+	ut.Expect(t, changed, 3)
+
+	//各服务器分别保存配置,交替通知不触发回调
+	notify("/test/order/api/a/conf")
+	notify("/test/order/api/b/conf")
+	ut.Expect(t, changed, 3)
+	ut.Expect(t, w.Get().(*testAppConf).Limit, 10)
+	ut.Expect(t, w.Get("rpc").(*testAppConf).Limit, 30)
+	ut.Expect(t, w.Get("cron").(*testAppConf).Limit, 0)
+
+	//服务器关闭后不再返回该服务器的配置
+	data, version, _ := rgst.GetValue("/test/order/api/a/conf")
+	c, _ := NewServerConf("/test/order/api/a/conf", data, version, rgst)
+	RemoveAppConf(c)
+	ut.Expect(t, w.Get().(*testAppConf).Limit, 20)
+	RemoveAppConf(c)
+	notify("/test/order/api/a/conf")
+	ut.Expect(t, changed, 4)
+}
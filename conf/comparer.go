@@ -1,5 +1,7 @@
 package conf
 
+import "sort"
+
 type Comparer struct {
 	Oconf IServerConf
 	Nconf IServerConf
@@ -14,9 +16,24 @@ func NewComparer(Oconf IServerConf, Nconf IServerConf) *Comparer {
 	}
 }
 
+//IsChanged 主配置,子节点或var节点是否发生变化
+func (s *Comparer) IsChanged() bool {
+	return s.JSONComparer.IsChanged() || len(s.GetChangedSubConfs()) > 0 || s.IsVarChanged()
+}
+
 //IsVarChanged var节点是否发生变化
 func (s *Comparer) IsVarChanged() bool {
-	return s.Oconf.GetVarVersion() != s.Nconf.GetVarVersion()
+	return s.Oconf.GetVarVersion() != s.Nconf.GetVarVersion() || len(s.GetChangedVarConfs()) > 0
+}
+
+//GetChangedVarConfs 获取新增,删除或内容变化的var节点,名称格式为 类型/名称(如db/db)
+func (s *Comparer) GetChangedVarConfs() []string {
+	return getChangedConfs(s.Oconf.GetVarConfClone(), s.Nconf.GetVarConfClone())
+}
+
+//GetChangedSubConfs 获取新增,删除或内容变化的子节点名称
+func (s *Comparer) GetChangedSubConfs() []string {
+	return getChangedConfs(s.Oconf.GetSubConfClone(), s.Nconf.GetSubConfClone())
 }
 
 //IsSubConfChanged 检查节点是否发生变化
@@ -29,7 +46,7 @@ func (s *Comparer) IsSubConfChanged(name string) (isChanged bool) {
 	if newConf == nil {
 		newConf = &JSONConf{version: 0}
 	}
-	return oldConf.version != newConf.version
+	return oldConf.version != newConf.version || oldConf.signature != newConf.signature
 }
 
 //IsRequiredSubConfChanged 检查必须节点是否发生变化
@@ -42,5 +59,23 @@ func (s *Comparer) IsRequiredSubConfChanged(name string) (isChanged bool, err er
 	if newConf == nil {
 		newConf = &JSONConf{version: 0}
 	}
-	return oldConf.GetVersion() != newConf.GetVersion(), nil
+	return oldConf.GetVersion() != newConf.GetVersion() || oldConf.GetSignature() != newConf.GetSignature(), nil
+}
+
+//getChangedConfs 比较节点版本号与签名,签名可反映密钥引用与本地覆盖配置的变化
+func getChangedConfs(o map[string]JSONConf, n map[string]JSONConf) []string {
+	names := make([]string, 0, 1)
+	for k, nc := range n {
+		oc, ok := o[k]
+		if !ok || oc.version != nc.version || oc.signature != nc.signature {
+			names = append(names, k)
+		}
+	}
+	for k := range o {
+		if _, ok := n[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
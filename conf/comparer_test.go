@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func TestComparerChangedConfs(t *testing.T) {
+	rgst := mem.New("TestComparerChangedConfs")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+	rgst.CreatePersistentNode("/test/order/api/t/conf/app", `{"name":"order"}`)
+	rgst.CreatePersistentNode("/test/order/api/t/conf/router", `{"routers":[]}`)
+	rgst.CreatePersistentNode("/test/var/db/db", `{"provider":"ora"}`)
+	rgst.CreatePersistentNode("/test/var/cache/cache", `{"proto":"redis"}`)
+	data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+	o, err := NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+
+	n, _ := NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	comparer := NewComparer(o, n)
+	ut.Expect(t, len(comparer.GetChangedVarConfs()), 0)
+	ut.Expect(t, len(comparer.GetChangedSubConfs()), 0)
+	ut.Expect(t, comparer.IsVarChanged(), false)
+
+	_, v, _ := rgst.GetValue("/test/var/db/db")
+	rgst.Update("/test/var/db/db", `{"provider":"mysql"}`, v)
+	rgst.CreatePersistentNode("/test/var/queue/queue", `{"proto":"redis"}`)
+	_, v, _ = rgst.GetValue("/test/order/api/t/conf/app")
+	rgst.Update("/test/order/api/t/conf/app", `{"name":"order1"}`, v)
+	rgst.Delete("/test/order/api/t/conf/router")
+	n, _ = NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	comparer = NewComparer(o, n)
+	ut.Expect(t, comparer.GetChangedVarConfs(), []string{"db/db", "queue/queue"})
+	ut.Expect(t, comparer.GetChangedSubConfs(), []string{"app", "router"})
+	ut.Expect(t, comparer.IsVarChanged(), true)
+}
+
+func TestComparerSignature(t *testing.T) {
+	rgst := mem.New("TestComparerSignature")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/mqc/t/conf", `{"status":"start"}`)
+	rgst.CreatePersistentNode("/test/order/mqc/t/conf/server", `{"proto":"redis","password":"123"}`)
+	data, version, _ := rgst.GetValue("/test/order/mqc/t/conf")
+	o, _ := NewServerConf("/test/order/mqc/t/conf", data, version, rgst)
+
+	//版本号不变,内容变化(如密钥更新)时仍视为变更
+	n, _ := NewServerConf("/test/order/mqc/t/conf", data, version, rgst)
+	server, _ := NewJSONConf([]byte(`{"proto":"redis","password":"456"}`), n.subNodeConfs["server"].version)
+	n.subNodeConfs["server"] = *server
+	comparer := NewComparer(o, n)
+	ut.Expect(t, comparer.IsChanged(), true)
+	ut.Expect(t, comparer.IsSubConfChanged("server"), true)
+	ok, _ := comparer.IsRequiredSubConfChanged("server")
+	ut.Expect(t, ok, true)
+
+	n, _ = NewServerConf("/test/order/mqc/t/conf", data, version, rgst)
+	comparer = NewComparer(o, n)
+	ut.Expect(t, comparer.IsChanged(), false)
+	ut.Expect(t, comparer.IsSubConfChanged("server"), false)
+}
@@ -0,0 +1,54 @@
+package creator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+//AnswerEnvPrefix 安装参数环境变量前缀,如参数#db_string对应的环境变量为parrot_param_db_string
+const AnswerEnvPrefix = "parrot_param_"
+
+//LoadAnswers 从json或yaml文件中加载安装参数值,文件内容为参数名与参数值的键值对
+func LoadAnswers(path string) (map[string]string, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取参数文件失败:%v", err)
+	}
+	input := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buff, &input)
+	default:
+		err = json.Unmarshal(buff, &input)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("参数文件%s格式错误:%v", path, err)
+	}
+	answers := make(map[string]string, len(input))
+	for k, v := range input {
+		switch v.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("参数文件%s中%s的值必须是字符串,数字或布尔值", path, k)
+		case nil:
+			answers[strings.TrimPrefix(k, "#")] = ""
+		default:
+			answers[strings.TrimPrefix(k, "#")] = fmt.Sprint(v)
+		}
+	}
+	return answers, nil
+}
+
+//getAnswer 获取参数值,优先从环境变量获取
+func getAnswer(param string, answers map[string]string) (string, bool) {
+	if v, ok := os.LookupEnv(AnswerEnvPrefix + param); ok {
+		return v, true
+	}
+	v, ok := answers[param]
+	return v, ok
+}
@@ -40,6 +40,9 @@ type IBinder interface {
 	GetMainConfScanNum(serverType string) int
 	GetSubConfScanNum(serverType string, subName string) int
 	GetVarConfScanNum(nodeName string) int
+	GetMainConfScanParams(serverType string) []string
+	GetSubConfScanParams(serverType string, subName string) []string
+	GetVarConfScanParams(nodeName string) []string
 	GetInstallers(serverType string) []func(c component.IContainer) error
 	GetSQL(dir string) ([]string, error)
 	GetInput() map[string]*Input
@@ -142,6 +145,21 @@ func (s *Binder) GetVarConfScanNum(nodeName string) int {
 	return s.Plat.needScanCount(nodeName)
 }
 
+//GetMainConfScanParams 获取主配置待扫描参数
+func (s *Binder) GetMainConfScanParams(serverType string) []string {
+	return s.binders[serverType].scanParams("")
+}
+
+//GetSubConfScanParams 获取子配置待扫描参数
+func (s *Binder) GetSubConfScanParams(serverType string, subName string) []string {
+	return s.binders[serverType].scanParams(subName)
+}
+
+//GetVarConfScanParams 获取var配置待扫描参数
+func (s *Binder) GetVarConfScanParams(nodeName string) []string {
+	return s.Plat.scanParams(nodeName)
+}
+
 //ScanMainConf 扫描主配置
 func (s *Binder) ScanMainConf(mainPath string, serverType string) error {
 	binder := s.binders[serverType]
@@ -2,10 +2,13 @@ package creator
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/conf/history"
 	"github.com/sereiner/parrot/engines"
 	"github.com/sereiner/parrot/registry"
 )
@@ -22,10 +25,47 @@ type Creator struct {
 	serverTypes  []string
 	clusterName  string
 	customer     func() error
+	mode         string
+	answers      map[string]string
+	interactive  bool
+	dryRun       bool
+	out          io.Writer
+	history      *history.History
+}
+
+//Option 配置文件创建器选项
+type Option func(*Creator)
+
+//WithMode 设置安装模式,支持skip(1):存在则不安装,cover(2):存在则覆盖,new(3):删除所有配置并重建
+func WithMode(mode string) Option {
+	return func(c *Creator) {
+		c.mode = mode
+	}
+}
+
+//WithAnswers 设置安装参数值
+func WithAnswers(answers map[string]string) Option {
+	return func(c *Creator) {
+		c.answers = answers
+	}
+}
+
+//WithNonInteractive 非交互模式,不从标准输入读取参数,参数未设置时安装失败
+func WithNonInteractive() Option {
+	return func(c *Creator) {
+		c.interactive = false
+	}
+}
+
+//WithDryRun 只输出安装计划及与注册中心现有配置的差异,不修改注册中心
+func WithDryRun() Option {
+	return func(c *Creator) {
+		c.dryRun = true
+	}
 }
 
 //NewCreator 配置文件创建器
-func NewCreator(platName string, systemName string, serverTypes []string, clusterName string, binder IBinder, registryAddr string, rgst registry.IRegistry, logger logger.ILogging) (w *Creator) {
+func NewCreator(platName string, systemName string, serverTypes []string, clusterName string, binder IBinder, registryAddr string, rgst registry.IRegistry, logger logger.ILogging, opts ...Option) (w *Creator) {
 	w = &Creator{
 		platName:     platName,
 		systemName:   systemName,
@@ -35,12 +75,34 @@ func NewCreator(platName string, systemName string, serverTypes []string, cluste
 		registryAddr: registryAddr,
 		logger:       logger,
 		binder:       binder,
+		answers:      make(map[string]string),
+		interactive:  true,
+		out:          os.Stdout,
+		history:      history.New(rgst),
+	}
+	for _, opt := range opts {
+		opt(w)
 	}
 	return
 }
 func (c *Creator) installParams() error {
 	//检查必须输入参数
 	input := c.binder.GetInput()
+	for k := range input {
+		if strings.HasPrefix(k, "#") {
+			continue
+		}
+		if v, ok := getAnswer(k, c.answers); ok {
+			nvalue, err := filterInput(k, v, input)
+			if err != nil {
+				return fmt.Errorf("参数%s的值无效:%v", k, err)
+			}
+			c.binder.SetParam(k, nvalue)
+		}
+	}
+	if !c.interactive {
+		return nil
+	}
 	if len(input) > 0 {
 		if !c.binder.Confirm("设置基础参数值(这些参数用于创建配置数据)?") {
 			return nil
@@ -50,6 +112,9 @@ func (c *Creator) installParams() error {
 		if strings.HasPrefix(k, "#") {
 			continue
 		}
+		if _, ok := getAnswer(k, c.answers); ok {
+			continue
+		}
 		nvalue, err := getInputValue(k, input, "")
 		if err != nil {
 			return err
@@ -60,101 +125,26 @@ func (c *Creator) installParams() error {
 }
 func (c *Creator) installRegistry() error {
 	//检查配置模式
-	mode, cn := c.checkRegistry()
+	mode, cn, err := c.checkRegistry()
+	if err != nil {
+		return err
+	}
 	//创建主配置
 	if !cn {
 		return nil
 	}
-	for _, tp := range c.serverTypes {
-		mainPath := registry.Join("/", c.platName, c.systemName, tp, c.clusterName, "conf")
-		rpath := c.getRealMainPath(mainPath)
-		ok, err := c.registry.Exists(rpath)
-		if err != nil {
-			return err
-		}
-		if ok && mode == modeAuto {
-			continue
-		}
-		pc, _, _ := c.registry.GetChildren(rpath)
-		for _, v := range pc {
-			c.registry.Delete(registry.Join(rpath, v))
-		}
-		b, _ := c.registry.Exists(rpath)
-		if b {
-			err = c.registry.Delete(rpath)
-			if err != nil {
-				return fmt.Errorf("%v,delete path: %s failed", err, rpath)
-			}
-			if mode == modeNew {
-				c.logger.Info("\t\t删除配置:", rpath)
-			}
-		}
-
-		if err := c.binder.ScanMainConf(mainPath, tp); err != nil {
-			return err
-		}
-		content := c.binder.GetMainConf(tp)
-		if ok && mode == modeCover {
-			if err := c.createMainConf(mainPath, content); err != nil {
-				return err
-			}
-			c.logger.Info("\t\t修改配置:", mainPath)
-		}
-		if err := c.createMainConf(mainPath, content); err != nil {
-			return err
-		}
-		c.logger.Info("\t\t创建配置:", mainPath)
+	if err := c.bindAnswers(mode); err != nil {
+		return err
 	}
-	//检查子配置
-	for _, tp := range c.serverTypes {
-		mainPath := registry.Join("/", c.platName, c.systemName, tp, c.clusterName, "conf")
-		subNames := c.binder.GetSubConfNames(tp)
-		for _, subName := range subNames {
-			ok, err := c.registry.Exists(registry.Join(mainPath, subName))
-			if err != nil {
-				return err
-			}
-			if ok && mode == modeAuto {
-				continue
-			}
-			//删除配置重建
-			c.registry.Delete(registry.Join(mainPath, subName))
-			if err := c.binder.ScanSubConf(mainPath, tp, subName); err != nil {
-				return err
-			}
-
-			path := registry.Join("/", mainPath, subName)
-			content := c.binder.GetSubConf(tp, subName)
-			if err := c.createConf(path, content); err != nil {
-				return err
-			}
-			c.logger.Info("\t\t创建配置:", path)
-		}
+	steps, err := c.getPlan(mode)
+	if err != nil {
+		return err
 	}
-
-	//检查平台配置
-	varNames := c.binder.GetVarConfNames()
-	for _, varName := range varNames {
-		ok, err := c.registry.Exists(registry.Join("/", c.platName, "var", varName))
-		if err != nil {
-			return err
-		}
-		if ok && mode == modeAuto {
-			continue
-		}
-		//删除配置重建
-		c.registry.Delete(registry.Join("/", c.platName, "var", varName))
-		if err := c.binder.ScanVarConf(c.platName, varName); err != nil {
-			return err
-		}
-		path := registry.Join("/", c.platName, "var", varName)
-		content := c.binder.GetVarConf(varName)
-		if err := c.createConf(path, content); err != nil {
-			return err
-		}
-		c.logger.Info("\t\t创建配置:", path)
+	if c.dryRun {
+		c.printPlan(steps)
+		return nil
 	}
-	return nil
+	return c.apply(steps)
 }
 
 //Start 扫描并绑定所有参数
@@ -165,6 +155,9 @@ func (c *Creator) Start() (err error) {
 	if err = c.installRegistry(); err != nil {
 		return err
 	}
+	if c.dryRun {
+		return nil
+	}
 	//执行用户自定义安装
 	if err = c.customerInstall(); err != nil {
 		return fmt.Errorf("安装程序执行失败:%v", err)
@@ -201,13 +194,6 @@ func (c *Creator) customerInstall() error {
 	return nil
 }
 
-func (c *Creator) createConf(path string, data string) error {
-	if data == "" {
-		return nil
-	}
-	return c.registry.CreatePersistentNode(path, data)
-}
-
 func (c *Creator) getRealMainPath(path string) string {
 	extPath := ""
 	if !c.registry.CanWirteDataInDir() {
@@ -215,39 +201,104 @@ func (c *Creator) getRealMainPath(path string) string {
 	}
 	return registry.Join(path, extPath)
 }
-func (c *Creator) createMainConf(path string, data string) error {
-	if data == "" {
-		data = "{}"
+
+//updateConf 按规划时读取的版本号更新配置,配置已被他人修改时返回冲突错误
+func (c *Creator) updateConf(name string, rpath string, data string, version int32) error {
+	err := c.registry.Update(rpath, data, version)
+	if registry.IsVersionConflict(err) {
+		return fmt.Errorf("配置%s已被修改,请重新执行安装:%v", name, err)
 	}
-	rpath := c.getRealMainPath(path)
-	return c.registry.CreatePersistentNode(rpath, data)
+	return err
 }
-func (c *Creator) updateMainConf(path string, data string) error {
-	if data == "" {
-		data = "{}"
+
+func (c *Creator) checkRegistry() (mode int, cn bool, err error) {
+	if c.mode != "" {
+		if mode, cn = parseMode(c.mode); !cn {
+			return 0, false, fmt.Errorf("安装模式%s无效,支持的模式有:skip(1),cover(2),new(3)", c.mode)
+		}
+		return mode, true, nil
 	}
-	rpath := c.getRealMainPath(path)
-	_, v, err := c.registry.GetValue(rpath)
-	if err != nil {
-		return err
+	if !c.interactive {
+		return 0, false, fmt.Errorf("非交互模式下必须指定安装模式:skip(1),cover(2),new(3)")
 	}
-	return c.registry.Update(rpath, data, v)
-}
-
-func (c *Creator) checkRegistry() (mode int, cn bool) {
 	msg := "创建注册中心配置数据?如存在则不安装(1),如果存在则覆盖(2),删除所有配置并重建(3),退出(n|no):"
 
 	var value string
 	fmt.Print("\t\033[;33m-> " + msg + "\033[0m")
 	fmt.Scan(&value)
-	nvalue := strings.ToUpper(value)
-	switch nvalue {
-	case "1":
+	mode, cn = parseMode(value)
+	return mode, cn, nil
+}
+
+//parseMode 解析安装模式
+func parseMode(value string) (mode int, ok bool) {
+	switch strings.ToLower(value) {
+	case "1", "skip":
 		return modeAuto, true
-	case "2":
+	case "2", "cover":
 		return modeCover, true
-	case "3":
+	case "3", "new":
 		return modeNew, true
 	}
 	return 0, false
 }
+
+//bindAnswers 使用参数文件与环境变量设置待安装配置的参数值,非交互模式下存在未设置的参数时返回错误
+func (c *Creator) bindAnswers(mode int) error {
+	params := make([]string, 0, 4)
+	for _, tp := range c.serverTypes {
+		mainPath := registry.Join("/", c.platName, c.systemName, tp, c.clusterName, "conf")
+		ok, err := c.needInstall(c.getRealMainPath(mainPath), mode)
+		if err != nil {
+			return err
+		}
+		if ok {
+			params = append(params, c.binder.GetMainConfScanParams(tp)...)
+		}
+		for _, subName := range c.binder.GetSubConfNames(tp) {
+			ok, err := c.needInstall(registry.Join(mainPath, subName), mode)
+			if err != nil {
+				return err
+			}
+			if ok {
+				params = append(params, c.binder.GetSubConfScanParams(tp, subName)...)
+			}
+		}
+	}
+	for _, varName := range c.binder.GetVarConfNames() {
+		ok, err := c.needInstall(registry.Join("/", c.platName, "var", varName), mode)
+		if err != nil {
+			return err
+		}
+		if ok {
+			params = append(params, c.binder.GetVarConfScanParams(varName)...)
+		}
+	}
+	input := c.binder.GetInput()
+	missing := make([]string, 0, 1)
+	for _, p := range unbound(params, map[string]string{}) {
+		v, ok := getAnswer(p, c.answers)
+		if !ok {
+			missing = append(missing, p)
+			continue
+		}
+		nvalue, err := filterInput(p, v, input)
+		if err != nil {
+			return fmt.Errorf("参数%s的值无效:%v", p, err)
+		}
+		c.binder.SetParam(p, nvalue)
+	}
+	if len(missing) > 0 && !c.interactive {
+		return fmt.Errorf("以下参数未设置,请通过参数文件或环境变量(%s参数名)指定:%s", AnswerEnvPrefix, strings.Join(missing, ","))
+	}
+	return nil
+}
+
+//needInstall 检查节点是否需要安装
+func (c *Creator) needInstall(path string, mode int) (bool, error) {
+	if mode != modeAuto {
+		return true, nil
+	}
+	ok, err := c.registry.Exists(path)
+	return !ok, err
+}
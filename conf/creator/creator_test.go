@@ -0,0 +1,129 @@
+package creator
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func newTestBinder() *Binder {
+	b := NewBinder(logger.New("creator"))
+	b.API.SetMain(conf.NewAPIServerConf(":#api_port"))
+	b.Plat.SetDB(conf.NewOracleConfForProd("#db_string"))
+	return b
+}
+
+func TestLoadAnswers(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "parrot-creator")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "answers.yaml")
+	ioutil.WriteFile(path, []byte("api_port: 8090\n\"#db_string\": order/123456@orcl136\n"), 0666)
+	answers, err := LoadAnswers(path)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, answers["api_port"], "8090")
+	ut.Expect(t, answers["db_string"], "order/123456@orcl136")
+
+	path = filepath.Join(dir, "answers.json")
+	ioutil.WriteFile(path, []byte(`{"api_port":{"v":1}}`), 0666)
+	_, err = LoadAnswers(path)
+	ut.Refute(t, err, nil)
+}
+
+func TestNonInteractiveInstall(t *testing.T) {
+	rgst := mem.New("TestNonInteractiveInstall")
+	defer rgst.Close()
+
+	//未指定安装模式
+	c := NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestNonInteractiveInstall", rgst, logger.New("creator"), WithNonInteractive())
+	ut.Refute(t, c.Start(), nil)
+
+	//安装模式无效
+	c = NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestNonInteractiveInstall", rgst, logger.New("creator"), WithMode("4"))
+	ut.Refute(t, c.Start(), nil)
+
+	//缺少参数时不修改注册中心
+	c = NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestNonInteractiveInstall", rgst, logger.New("creator"),
+		WithMode("skip"), WithNonInteractive(), WithAnswers(map[string]string{"api_port": "8090"}))
+	ut.Refute(t, c.Start(), nil)
+	b, _ := rgst.Exists("/test/order/api/t/conf")
+	ut.Expect(t, b, false)
+
+	os.Setenv(AnswerEnvPrefix+"db_string", "order/123456@orcl136")
+	defer os.Unsetenv(AnswerEnvPrefix + "db_string")
+	c = NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestNonInteractiveInstall", rgst, logger.New("creator"),
+		WithMode("skip"), WithNonInteractive(), WithAnswers(map[string]string{"api_port": "8090"}))
+	ut.Expect(t, c.Start(), nil)
+	data, _, _ := rgst.GetValue("/test/order/api/t/conf")
+	ut.Expect(t, string(data), `{"address":":8090"}`)
+	b, _ = rgst.Exists("/test/var/db/db")
+	ut.Expect(t, b, true)
+}
+
+func TestDryRun(t *testing.T) {
+	rgst := mem.New("TestDryRun")
+	defer rgst.Close()
+	answers := map[string]string{"api_port": "8090", "db_string": "order/123456@orcl136"}
+	c := NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestDryRun", rgst, logger.New("creator"),
+		WithMode("new"), WithNonInteractive(), WithAnswers(answers))
+	ut.Expect(t, c.Start(), nil)
+
+	answers["api_port"] = "9090"
+	c = NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestDryRun", rgst, logger.New("creator"),
+		WithMode("new"), WithNonInteractive(), WithAnswers(answers), WithDryRun())
+	out := bytes.NewBufferString("")
+	c.out = out
+	ut.Expect(t, c.Start(), nil)
+	ut.Expect(t, strings.Contains(out.String(), "[overwrite]\033[0m /test/order/api/t/conf\n"), true)
+	ut.Expect(t, strings.Contains(out.String(), "@@ -1,3 +1,3 @@\n {\n-    \"address\": \":8090\"\n+    \"address\": \":9090\"\n }\n"), true)
+
+	//注册中心未修改
+	data, _, _ := rgst.GetValue("/test/order/api/t/conf")
+	ut.Expect(t, string(data), `{"address":":8090"}`)
+}
+
+func TestCover(t *testing.T) {
+	rgst := mem.New("TestCover")
+	defer rgst.Close()
+	answers := map[string]string{"api_port": "8090", "db_string": "order/123456@orcl136"}
+	c := NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestCover", rgst, logger.New("creator"),
+		WithMode("new"), WithNonInteractive(), WithAnswers(answers))
+	ut.Expect(t, c.Start(), nil)
+	_, v1, _ := rgst.GetValue("/test/var/db/db")
+
+	//覆盖模式下按版本号更新已存在的平台配置
+	answers["db_string"] = "order/654321@orcl136"
+	c = NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestCover", rgst, logger.New("creator"),
+		WithMode("cover"), WithNonInteractive(), WithAnswers(answers))
+	ut.Expect(t, c.Start(), nil)
+	data, v2, _ := rgst.GetValue("/test/var/db/db")
+	ut.Expect(t, strings.Contains(string(data), "order/654321@orcl136"), true)
+	ut.Expect(t, v2, v1+1)
+}
+
+func TestCoverConflict(t *testing.T) {
+	rgst := mem.New("TestCoverConflict")
+	defer rgst.Close()
+	answers := map[string]string{"api_port": "8090", "db_string": "order/123456@orcl136"}
+	c := NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestCoverConflict", rgst, logger.New("creator"),
+		WithMode("new"), WithNonInteractive(), WithAnswers(answers))
+	ut.Expect(t, c.Start(), nil)
+
+	c = NewCreator("test", "order", []string{"api"}, "t", newTestBinder(), "mem://TestCoverConflict", rgst, logger.New("creator"),
+		WithMode("cover"), WithNonInteractive(), WithAnswers(answers))
+	steps, err := c.getPlan(modeCover)
+	ut.Expect(t, err, nil)
+
+	//规划后配置被他人修改,安装失败且不覆盖他人的修改
+	rgst.Update("/test/var/db/db", `{"provider":"ora"}`, -1)
+	ut.Refute(t, c.apply(steps), nil)
+	data, _, _ := rgst.GetValue("/test/var/db/db")
+	ut.Expect(t, string(data), `{"provider":"ora"}`)
+}
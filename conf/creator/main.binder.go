@@ -20,6 +20,7 @@ type imainBinder interface {
 	getSubConfNames() []string
 	scan(mainConf string, nodeName string) error
 	needScanCount(nodeName string) int
+	scanParams(nodeName string) []string
 	getNodeConf(nodeName string) string
 	getInstallers() []func(c component.IContainer) error
 	Installer(func(c component.IContainer) error)
@@ -57,7 +58,7 @@ func (c *mainBinder) Installer(f func(c component.IContainer) error) {
 	c.installers = append(c.installers, f)
 }
 
-//SetMainConf 设置主配置内容
+//SetMainConf 设置主配置内容,input为结构体,json字符串或包含格式标识的yaml,toml字符串(见conf.WithFormat)
 func (c *mainBinder) SetMainConf(input interface{}) {
 	s, err := getConfig(input)
 	if err != nil {
@@ -67,7 +68,7 @@ func (c *mainBinder) SetMainConf(input interface{}) {
 	c.mainParamsForInput = getParams(s)
 }
 
-//SetSubConf 设置子配置内容
+//SetSubConf 设置子配置内容,input格式同SetMainConf
 func (c *mainBinder) SetSubConf(n string, input interface{}) {
 	s, err := getConfig(input)
 	if err != nil {
@@ -117,6 +118,16 @@ func (c *mainBinder) needScanCount(nodeName string) int {
 	return count
 }
 
+//scanParams 待输入的参数名称
+func (c *mainBinder) scanParams(nodeName string) []string {
+	params := make([]string, 0, 2)
+	if nodeName == "" {
+		params = append(params, c.mainParamsForInput...)
+	}
+	params = append(params, c.subParamsForInput[nodeName]...)
+	return unbound(params, c.params)
+}
+
 //Scan 绑定参数
 func (c *mainBinder) scan(mainConf string, nodeName string) (err error) {
 	if nodeName == "" {
@@ -218,21 +229,42 @@ func getInputValue(param string, inputs map[string]*Input, path string) (v strin
 
 	var value string
 	fmt.Scan(&value)
+	return filterInput(param, value, inputs)
+}
+
+//filterInput 使用参数的过滤器处理输入值
+func filterInput(param string, value string, inputs map[string]*Input) (v string, err error) {
 	nvalue := value
-	if input != nil {
+	if input, ok := inputs["#"+param]; ok {
 		for _, f := range input.Filters {
 			if nvalue, err = f(nvalue); err != nil {
 				return "", err
 			}
 		}
 	}
-
 	return nvalue, nil
 }
+
+//unbound 获取未设置值的参数,去除重复项
+func unbound(params []string, values map[string]string) []string {
+	r := make([]string, 0, len(params))
+	exists := make(map[string]bool)
+	for _, p := range params {
+		if _, ok := values[p]; ok || exists[p] {
+			continue
+		}
+		exists[p] = true
+		r = append(r, p)
+	}
+	return r
+}
+//getConfig 获取配置内容,字符串与[]byte原样保存,yaml,toml内容需包含格式标识
 func getConfig(i interface{}) (string, error) {
 	switch v := i.(type) {
 	case string:
 		return v, nil
+	case []byte:
+		return string(v), nil
 	default:
 		buff, err := json.Marshal(i)
 		if err != nil {
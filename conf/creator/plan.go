@@ -0,0 +1,224 @@
+package creator
+
+import (
+	"fmt"
+
+	"github.com/sereiner/parrot/conf/diff"
+	"github.com/sereiner/parrot/conf/history"
+	"github.com/sereiner/parrot/registry"
+)
+
+const (
+	actionCreate = "create"
+	actionUpdate = "update"
+	actionDelete = "delete"
+)
+
+//step 安装步骤
+type step struct {
+	action  string
+	path    string //注册中心中的实际路径
+	name    string //配置名称
+	old     string //注册中心中的当前配置
+	newData string //待安装的配置
+	version int32
+	main    bool
+	mode    int
+}
+
+//getPlan 扫描参数并生成安装步骤,不修改注册中心
+func (c *Creator) getPlan(mode int) ([]*step, error) {
+	steps := make([]*step, 0, 8)
+	deleted := make(map[string]bool)
+	for _, tp := range c.serverTypes {
+		mainPath := registry.Join("/", c.platName, c.systemName, tp, c.clusterName, "conf")
+		rpath := c.getRealMainPath(mainPath)
+		ok, err := c.registry.Exists(rpath)
+		if err != nil {
+			return nil, err
+		}
+		if ok && mode == modeAuto {
+			continue
+		}
+		if ok && mode == modeCover {
+			//记录输入参数前的版本号,期间配置被他人修改时不覆盖
+			old, version, err := c.registry.GetValue(rpath)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.binder.ScanMainConf(mainPath, tp); err != nil {
+				return nil, err
+			}
+			steps = append(steps, &step{action: actionUpdate, path: rpath, name: mainPath, old: string(old),
+				newData: mainConf(c.binder.GetMainConf(tp)), version: version, main: true})
+			continue
+		}
+		if ok {
+			pc, _, _ := c.registry.GetChildren(rpath)
+			for _, v := range pc {
+				steps = append(steps, c.deleteStep(registry.Join(rpath, v), mode, false))
+				deleted[registry.Join(rpath, v)] = true
+			}
+			steps = append(steps, c.deleteStep(rpath, mode, true))
+		}
+		if err := c.binder.ScanMainConf(mainPath, tp); err != nil {
+			return nil, err
+		}
+		steps = append(steps, &step{action: actionCreate, path: rpath, name: mainPath, newData: mainConf(c.binder.GetMainConf(tp)), main: true})
+	}
+	//检查子配置
+	for _, tp := range c.serverTypes {
+		mainPath := registry.Join("/", c.platName, c.systemName, tp, c.clusterName, "conf")
+		for _, subName := range c.binder.GetSubConfNames(tp) {
+			path := registry.Join("/", mainPath, subName)
+			nsteps, err := c.getNodePlan(path, mode, deleted, func() (string, error) {
+				err := c.binder.ScanSubConf(mainPath, tp, subName)
+				return c.binder.GetSubConf(tp, subName), err
+			})
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, nsteps...)
+		}
+	}
+
+	//检查平台配置
+	for _, varName := range c.binder.GetVarConfNames() {
+		path := registry.Join("/", c.platName, "var", varName)
+		nsteps, err := c.getNodePlan(path, mode, deleted, func() (string, error) {
+			err := c.binder.ScanVarConf(c.platName, varName)
+			return c.binder.GetVarConf(varName), err
+		})
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, nsteps...)
+	}
+	return steps, nil
+}
+
+//getNodePlan 生成子配置或平台配置的安装步骤,覆盖模式下按版本号更新已存在的配置,其它模式先删除再重建
+func (c *Creator) getNodePlan(path string, mode int, deleted map[string]bool, scan func() (string, error)) ([]*step, error) {
+	ok, err := c.registry.Exists(path)
+	if err != nil {
+		return nil, err
+	}
+	ok = ok && !deleted[path]
+	if ok && mode == modeAuto {
+		return nil, nil
+	}
+	if ok && mode == modeCover {
+		//记录输入参数前的版本号,期间配置被他人修改时不覆盖
+		old, version, err := c.registry.GetValue(path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := scan()
+		if err != nil || content == "" {
+			return nil, err
+		}
+		return []*step{{action: actionUpdate, path: path, name: path, old: string(old), newData: content, version: version}}, nil
+	}
+	steps := make([]*step, 0, 2)
+	if ok {
+		steps = append(steps, c.deleteStep(path, mode, false))
+	}
+	content, err := scan()
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		steps = append(steps, &step{action: actionCreate, path: path, name: path, newData: content})
+	}
+	return steps, nil
+}
+
+func (c *Creator) deleteStep(path string, mode int, main bool) *step {
+	old, _, _ := c.registry.GetValue(path)
+	return &step{action: actionDelete, path: path, name: path, old: string(old), main: main, mode: mode}
+}
+
+//apply 执行安装步骤
+func (c *Creator) apply(steps []*step) error {
+	for _, s := range steps {
+		switch s.action {
+		case actionDelete:
+			err := c.registry.Delete(s.path)
+			if !s.main {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%v,delete path: %s failed", err, s.path)
+			}
+			if s.mode == modeNew {
+				c.logger.Info("\t\t删除配置:", s.path)
+			}
+		case actionUpdate:
+			if err := c.updateConf(s.name, s.path, s.newData, s.version); err != nil {
+				return err
+			}
+			c.logger.Info("\t\t修改配置:", s.name)
+			c.recordHistory(s)
+		case actionCreate:
+			if err := c.registry.CreatePersistentNode(s.path, s.newData); err != nil {
+				return err
+			}
+			c.logger.Info("\t\t创建配置:", s.name)
+			c.recordHistory(s)
+		}
+	}
+	return nil
+}
+
+//recordHistory 记录安装的配置及操作人,记录失败不影响安装
+func (c *Creator) recordHistory(s *step) {
+	_, version, err := c.registry.GetValue(s.path)
+	if err == nil {
+		_, err = c.history.Record(s.name, []byte(s.newData), version, history.ActionInstall)
+	}
+	if err != nil {
+		c.logger.Warnf("配置历史记录失败:%v", err)
+	}
+}
+
+//printPlan 输出安装计划,同一节点先删除后创建时合并为覆盖
+func (c *Creator) printPlan(steps []*step) {
+	created := make(map[string]*step)
+	for _, s := range steps {
+		if s.action == actionCreate {
+			created[s.path] = s
+		}
+	}
+	recreated := make(map[string]bool)
+	for _, s := range steps {
+		if s.action == actionDelete && created[s.path] != nil {
+			recreated[s.path] = true
+		}
+	}
+	count := 0
+	for _, s := range steps {
+		action, old, newData := s.action, s.old, s.newData
+		switch {
+		case s.action == actionDelete && recreated[s.path]:
+			continue
+		case s.action == actionCreate && recreated[s.path]:
+			action = "overwrite"
+			for _, d := range steps {
+				if d.action == actionDelete && d.path == s.path {
+					old = d.old
+				}
+			}
+		}
+		count++
+		fmt.Fprintf(c.out, "\033[;33m[%s]\033[0m %s\n", action, s.name)
+		fmt.Fprint(c.out, diff.Unified(s.name+" (registry)", s.name+" (install)", diff.FormatJSON(old), diff.FormatJSON(newData)))
+	}
+	fmt.Fprintf(c.out, "共%d项变更(dry-run,未修改注册中心)\n", count)
+}
+
+func mainConf(data string) string {
+	if data == "" {
+		return "{}"
+	}
+	return data
+}
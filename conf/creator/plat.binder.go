@@ -11,6 +11,7 @@ type iplatBinder interface {
 	scan(platName string, nodeName string) error
 	getVarNames() []string
 	needScanCount(nodeName string) int
+	scanParams(nodeName string) []string
 	getNodeConf(nodeName string) string
 }
 
@@ -34,7 +35,7 @@ func newPlatBinder(params map[string]string, inputs map[string]*Input) *platBind
 	}
 }
 
-//SetVarConf 设置var配置内容
+//SetVarConf 设置var配置内容,input为结构体,json字符串或包含格式标识的yaml,toml字符串(见conf.WithFormat)
 func (c *platBinder) SetVarConf(t string, s string, input interface{}) {
 	v, err := getConfig(input)
 	if err != nil {
@@ -65,6 +66,11 @@ func (c *platBinder) needScanCount(nodeName string) int {
 	return count
 }
 
+//scanParams 待输入的参数名称
+func (c *platBinder) scanParams(nodeName string) []string {
+	return unbound(c.varParamsForInput[nodeName], c.params)
+}
+
 //Scan 绑定参数
 func (c *platBinder) scan(platName string, nodeName string) error {
 	for _, p := range c.varParamsForInput[nodeName] {
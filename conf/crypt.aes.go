@@ -0,0 +1,260 @@
+package conf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//AESGCMMode AES-GCM加密模式名称,密文格式为 encrypt:aes-gcm:密钥编号:base64(nonce+密文)
+const AESGCMMode = "aes-gcm"
+
+//CryptKeyEnv 密钥环境变量,格式为 密钥编号:base64密钥,多个密钥用逗号分隔,第一个为当前加密使用的密钥
+const CryptKeyEnv = "parrot_conf_key"
+
+//CryptKeyFileEnv 密钥文件路径环境变量,未设置时使用 ~/.parrot/conf.keys
+const CryptKeyFileEnv = "parrot_conf_key_file"
+
+//CryptKeys 密钥文件内容,Current为当前加密使用的密钥编号,Keys为密钥编号与base64密钥
+type CryptKeys struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+//AESGCMCrypt AES-GCM加解密,密钥编号保存在密文头中,更换密钥后仍可解密旧密钥加密的内容
+type AESGCMCrypt struct {
+	current string
+	keys    map[string][]byte
+}
+
+//NewAESGCMCrypt 构建AES-GCM加解密,密钥长度必须是16,24或32字节
+func NewAESGCMCrypt(current string, keys map[string][]byte) (*AESGCMCrypt, error) {
+	for id, k := range keys {
+		if strings.ContainsAny(id, ":,") || id == "" {
+			return nil, fmt.Errorf("密钥编号%s不能为空或包含':',','", id)
+		}
+		if _, err := aes.NewCipher(k); err != nil {
+			return nil, fmt.Errorf("密钥%s无效:%v", id, err)
+		}
+	}
+	if _, ok := keys[current]; current != "" && !ok {
+		return nil, fmt.Errorf("当前密钥%s不存在", current)
+	}
+	return &AESGCMCrypt{current: current, keys: keys}, nil
+}
+
+//LoadAESGCMCrypt 从环境变量或密钥文件加载密钥,keyFile为空时使用环境变量parrot_conf_key_file或默认密钥文件
+func LoadAESGCMCrypt(keyFile string) (*AESGCMCrypt, error) {
+	if v, ok := os.LookupEnv(CryptKeyEnv); ok && keyFile == "" {
+		return parseCryptKeyEnv(v)
+	}
+	keys, err := ReadCryptKeys(GetCryptKeyFile(keyFile))
+	if err != nil {
+		return nil, err
+	}
+	return keys.NewCrypt()
+}
+
+//GetCryptKeyFile 获取密钥文件路径
+func GetCryptKeyFile(keyFile string) string {
+	if keyFile != "" {
+		return keyFile
+	}
+	if v := os.Getenv(CryptKeyFileEnv); v != "" {
+		return v
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".parrot", "conf.keys")
+	}
+	return filepath.Join(home, ".parrot", "conf.keys")
+}
+
+//ReadCryptKeys 读取密钥文件,文件不存在时返回空密钥
+func ReadCryptKeys(path string) (*CryptKeys, error) {
+	keys := &CryptKeys{Keys: make(map[string]string)}
+	buff, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(buff, keys); err != nil {
+		return nil, fmt.Errorf("密钥文件%s格式错误:%v", path, err)
+	}
+	if keys.Keys == nil {
+		keys.Keys = make(map[string]string)
+	}
+	return keys, nil
+}
+
+//Save 保存密钥文件,仅当前用户可读写
+func (k *CryptKeys) Save(path string) error {
+	buff, err := json.MarshalIndent(k, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buff, 0600)
+}
+
+//Generate 生成新密钥并设置为当前密钥
+func (k *CryptKeys) Generate(id string) error {
+	if id == "" || strings.ContainsAny(id, ":,") {
+		return fmt.Errorf("密钥编号%s不能为空或包含':',','", id)
+	}
+	if _, ok := k.Keys[id]; ok {
+		return fmt.Errorf("密钥%s已存在", id)
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+	k.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	k.Current = id
+	return nil
+}
+
+//NewCrypt 根据密钥构建AES-GCM加解密
+func (k *CryptKeys) NewCrypt() (*AESGCMCrypt, error) {
+	keys := make(map[string][]byte, len(k.Keys))
+	for id, v := range k.Keys {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("密钥%s不是有效的base64编码:%v", id, err)
+		}
+		keys[id] = key
+	}
+	return NewAESGCMCrypt(k.Current, keys)
+}
+
+func parseCryptKeyEnv(v string) (*AESGCMCrypt, error) {
+	keys := &CryptKeys{Keys: make(map[string]string)}
+	for _, item := range strings.Split(v, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("环境变量%s格式错误,格式为 密钥编号:base64密钥", CryptKeyEnv)
+		}
+		if keys.Current == "" {
+			keys.Current = kv[0]
+		}
+		keys.Keys[kv[0]] = kv[1]
+	}
+	return keys.NewCrypt()
+}
+
+//Mode 模式名称
+func (a *AESGCMCrypt) Mode() string {
+	return AESGCMMode
+}
+
+//KeyIDs 获取所有密钥编号
+func (a *AESGCMCrypt) KeyIDs() []string {
+	ids := make([]string, 0, len(a.keys))
+	for id := range a.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+//Encrypt 使用当前密钥加密数据
+func (a *AESGCMCrypt) Encrypt(input []byte) (string, error) {
+	if a.current == "" {
+		return "", fmt.Errorf("未配置加密密钥,请通过环境变量%s或密钥文件设置", CryptKeyEnv)
+	}
+	gcm, err := newGCM(a.keys[a.current])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	//密钥编号作为附加数据,防止密文头被篡改
+	v := gcm.Seal(nonce, nonce, input, []byte(a.current))
+	return fmt.Sprintf("%s:%s", a.current, base64.StdEncoding.EncodeToString(v)), nil
+}
+
+//Decrypt 根据密文头中的密钥编号解密数据
+func (a *AESGCMCrypt) Decrypt(mode string, data []byte) ([]byte, error) {
+	kv := strings.SplitN(string(data), ":", 2)
+	if len(kv) != 2 {
+		return nil, fmt.Errorf("密文格式错误,缺少密钥编号")
+	}
+	key, ok := a.keys[kv[0]]
+	if !ok {
+		return nil, fmt.Errorf("未找到密钥%s", kv[0])
+	}
+	src, err := base64.StdEncoding.DecodeString(kv[1])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(src) < gcm.NonceSize() {
+		return nil, fmt.Errorf("密文长度错误")
+	}
+	return gcm.Open(nil, src[:gcm.NonceSize()], src[gcm.NonceSize():], []byte(kv[0]))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//lazyAESGCMCrypt 首次使用时从环境变量或密钥文件加载密钥
+type lazyAESGCMCrypt struct {
+	once  sync.Once
+	crypt *AESGCMCrypt
+	err   error
+}
+
+func newLazyAESGCMCrypt() *lazyAESGCMCrypt {
+	return &lazyAESGCMCrypt{}
+}
+
+func (l *lazyAESGCMCrypt) load() (*AESGCMCrypt, error) {
+	l.once.Do(func() {
+		l.crypt, l.err = LoadAESGCMCrypt("")
+	})
+	return l.crypt, l.err
+}
+
+func (l *lazyAESGCMCrypt) Mode() string {
+	return AESGCMMode
+}
+
+func (l *lazyAESGCMCrypt) Encrypt(input []byte) (string, error) {
+	c, err := l.load()
+	if err != nil {
+		return "", err
+	}
+	return c.Encrypt(input)
+}
+
+func (l *lazyAESGCMCrypt) Decrypt(mode string, data []byte) ([]byte, error) {
+	c, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	return c.Decrypt(mode, data)
+}
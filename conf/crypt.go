@@ -1,9 +1,10 @@
 package conf
 
 import (
-	"fmt"
-
+	"bytes"
 	"encoding/hex"
+	"fmt"
+	"sync"
 
 	"github.com/sereiner/library/security/des"
 )
@@ -13,25 +14,114 @@ const confIV = "#*---iv*"
 const header = "encrypt"
 const mode = "cbc/pkcs5"
 
-//对加密内容进行des加密，并增加加密头
-func encrypt(input []byte) string {
-	v, _ := des.EncryptBytes(input, confKey, []byte(confIV), mode)
-	return fmt.Sprintf("%s:%s:%s", header, mode, hex.EncodeToString(v))
+//ICrypt 配置加解密提供程序,加密后的内容格式为 encrypt:模式名称:密文
+type ICrypt interface {
+	//Mode 模式名称
+	Mode() string
+	//Encrypt 加密数据,返回不含加密头的密文
+	Encrypt(input []byte) (string, error)
+	//Decrypt 解密不含加密头的密文
+	Decrypt(mode string, data []byte) ([]byte, error)
 }
 
-//检查是否包含加密头，报含则根据加密头数据解密数据
-func decrypt(data []byte) ([]byte, error) {
-	lheader := len(header)
-	lmode := len(mode)
-	if len(data) <= lheader+lmode+2 {
+var crypts = make(map[string]ICrypt)
+var cryptLock sync.RWMutex
+
+//defaultCryptMode 默认加密模式
+var defaultCryptMode = AESGCMMode
+
+func init() {
+	RegisterCrypt(&desCrypt{})
+	RegisterCrypt(newLazyAESGCMCrypt())
+}
+
+//RegisterCrypt 注册加解密提供程序,已存在的同名提供程序将被替换
+func RegisterCrypt(c ICrypt) {
+	cryptLock.Lock()
+	defer cryptLock.Unlock()
+	crypts[c.Mode()] = c
+}
+
+func getCrypt(mode string) (ICrypt, bool) {
+	cryptLock.RLock()
+	defer cryptLock.RUnlock()
+	c, ok := crypts[mode]
+	return c, ok
+}
+
+//Encrypt 使用默认加密模式加密数据,并增加加密头
+func Encrypt(input []byte) (string, error) {
+	return EncryptWithMode(defaultCryptMode, input)
+}
+
+//EncryptWithMode 使用指定模式加密数据,并增加加密头
+func EncryptWithMode(mode string, input []byte) (string, error) {
+	c, ok := getCrypt(mode)
+	if !ok {
+		return "", fmt.Errorf("不支持的加密模式:%s", mode)
+	}
+	v, err := c.Encrypt(input)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s:%s", header, c.Mode(), v), nil
+}
+
+//Decrypt 检查是否包含加密头，包含则根据加密头数据解密数据
+func Decrypt(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(header+":")) {
 		return data, nil
 	}
-	if string(data[0:lheader]) != header {
+	content := data[len(header)+1:]
+	index := bytes.IndexByte(content, ':')
+	if index <= 0 {
 		return data, nil
 	}
-	mode := string(data[lheader+1 : lheader+lmode+1])
-	src := make([]byte, (len(data)-lheader-lmode-2)/2)
-	if _, err := hex.Decode(src, data[(lheader+lmode+2):]); err != nil {
+	mode := string(content[:index])
+	if len(content) == index+1 {
+		return data, nil
+	}
+	c, ok := getCrypt(mode)
+	if !ok {
+		//未注册的模式使用des解密
+		c = &desCrypt{}
+	}
+	r, err := c.Decrypt(mode, content[index+1:])
+	if err != nil {
+		return nil, fmt.Errorf("配置解密失败(%s):%v", mode, err)
+	}
+	return r, nil
+}
+
+//对加密内容进行加密，并增加加密头
+func encrypt(input []byte) (string, error) {
+	return Encrypt(input)
+}
+
+//检查是否包含加密头，报含则根据加密头数据解密数据
+func decrypt(data []byte) ([]byte, error) {
+	return Decrypt(data)
+}
+
+//desCrypt des加解密,仅用于兼容已有的加密配置
+type desCrypt struct {
+}
+
+func (d *desCrypt) Mode() string {
+	return mode
+}
+
+func (d *desCrypt) Encrypt(input []byte) (string, error) {
+	v, err := des.EncryptBytes(input, confKey, []byte(confIV), mode)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(v), nil
+}
+
+func (d *desCrypt) Decrypt(mode string, data []byte) ([]byte, error) {
+	src := make([]byte, len(data)/2)
+	if _, err := hex.Decode(src, data); err != nil {
 		return nil, err
 	}
 	return des.DecryptBytes(src, confKey, []byte(confIV), mode)
@@ -2,14 +2,31 @@ package conf
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sereiner/library/ut"
 )
 
+//setTestCrypt 注册测试使用的aes-gcm加密,返回恢复原加密提供程序的函数
+func setTestCrypt(t *testing.T, current string, keys map[string][]byte) func() {
+	old, _ := getCrypt(AESGCMMode)
+	c, err := NewAESGCMCrypt(current, keys)
+	ut.Expect(t, err, nil)
+	RegisterCrypt(c)
+	return func() {
+		RegisterCrypt(old)
+	}
+}
+
 func TestCryptNow(t *testing.T) {
+	defer setTestCrypt(t, "k1", map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")})()
 	input := "parrot杨"
-	v := encrypt([]byte(input))
+	v, err := encrypt([]byte(input))
+	ut.Expect(t, err, nil)
 	fmt.Println(v)
 	r, err := decrypt([]byte(v))
 	ut.Expect(t, err, nil)
@@ -21,3 +38,62 @@ func TestCryptNow2(t *testing.T) {
 	ut.Expect(t, err, nil)
 	ut.Expect(t, string(r), input)
 }
+
+//TestCryptLegacy 原des加密使用9字节密钥,加密始终失败并输出空密文,解密时原样返回
+func TestCryptLegacy(t *testing.T) {
+	legacy := "encrypt:cbc/pkcs5:"
+	r, err := Decrypt([]byte(legacy))
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(r), legacy)
+
+	_, err = EncryptWithMode(mode, []byte(`{"address":":8090"}`))
+	ut.Refute(t, err, nil)
+	_, err = Decrypt([]byte(legacy + "0123456789abcdef"))
+	ut.Refute(t, err, nil)
+}
+
+func TestCryptRotate(t *testing.T) {
+	k1 := []byte("0123456789abcdef0123456789abcdef")
+	k2 := []byte("fedcba9876543210fedcba9876543210")
+	defer setTestCrypt(t, "k1", map[string][]byte{"k1": k1})()
+	v1, err := Encrypt([]byte(`{"address":":8090"}`))
+	ut.Expect(t, err, nil)
+	ut.Expect(t, strings.HasPrefix(v1, "encrypt:aes-gcm:k1:"), true)
+
+	//更换密钥后仍可解密旧密钥加密的内容
+	setTestCrypt(t, "k2", map[string][]byte{"k1": k1, "k2": k2})
+	v2, _ := Encrypt([]byte(`{"address":":8090"}`))
+	ut.Expect(t, strings.HasPrefix(v2, "encrypt:aes-gcm:k2:"), true)
+	r, err := Decrypt([]byte(v1))
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(r), `{"address":":8090"}`)
+
+	//密文头被篡改时解密失败
+	_, err = Decrypt([]byte(strings.Replace(v2, ":k2:", ":k1:", 1)))
+	ut.Refute(t, err, nil)
+
+	setTestCrypt(t, "k2", map[string][]byte{"k2": k2})
+	_, err = Decrypt([]byte(v1))
+	ut.Refute(t, err, nil)
+}
+
+func TestCryptKeys(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "parrot-crypt")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "conf.keys")
+
+	keys, err := ReadCryptKeys(path)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, keys.Generate("k1"), nil)
+	ut.Refute(t, keys.Generate("k1"), nil)
+	ut.Expect(t, keys.Save(path), nil)
+
+	c, err := LoadAESGCMCrypt(path)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, c.KeyIDs(), []string{"k1"})
+
+	_, err = parseCryptKeyEnv("k1:" + keys.Keys["k1"] + ",k0:MTIzNDU2Nzg5MGFiY2RlZg==")
+	ut.Expect(t, err, nil)
+	_, err = parseCryptKeyEnv("k1")
+	ut.Refute(t, err, nil)
+}
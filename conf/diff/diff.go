@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//diffContext 差异输出的上下文行数
+const diffContext = 3
+
+type diffLine struct {
+	kind byte
+	text string
+	a    int //当前行之前旧内容的行数
+	b    int //当前行之前新内容的行数
+}
+
+//FormatJSON 格式化json内容,便于逐行比较,非json内容原样返回
+func FormatJSON(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	buff, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return s
+	}
+	return string(buff)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+//Unified 生成unified格式的差异,oldName,newName为差异头中的名称,内容相同时返回空
+func Unified(oldName string, newName string, oldData string, newData string) string {
+	lines := diffLines(splitLines(oldData), splitLines(newData))
+	changes := make([]int, 0, 4)
+	for i, l := range lines {
+		if l.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	buff := bytes.NewBufferString("")
+	buff.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	for i := 0; i < len(changes); {
+		//合并间隔不超过两倍上下文的变更
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext {
+			j++
+		}
+		start, end := changes[i]-diffContext, changes[j]+diffContext+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		writeHunk(buff, lines[start:end])
+		i = j + 1
+	}
+	return buff.String()
+}
+
+func writeHunk(buff *bytes.Buffer, lines []diffLine) {
+	alen, blen := 0, 0
+	for _, l := range lines {
+		if l.kind != '+' {
+			alen++
+		}
+		if l.kind != '-' {
+			blen++
+		}
+	}
+	astart, bstart := lines[0].a, lines[0].b
+	if alen > 0 {
+		astart++
+	}
+	if blen > 0 {
+		bstart++
+	}
+	buff.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", astart, alen, bstart, blen))
+	for _, l := range lines {
+		buff.WriteByte(l.kind)
+		buff.WriteString(l.text)
+		buff.WriteString("\n")
+	}
+}
+
+//diffLines 基于最长公共子序列逐行比较
+func diffLines(a []string, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	lines := make([]diffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{kind: ' ', text: a[i], a: i, b: j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, diffLine{kind: '+', text: b[j], a: i, b: j})
+			j++
+		default:
+			lines = append(lines, diffLine{kind: '-', text: a[i], a: i, b: j})
+			i++
+		}
+	}
+	return lines
+}
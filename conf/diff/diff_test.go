@@ -0,0 +1,17 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/sereiner/library/ut"
+)
+
+func TestUnified(t *testing.T) {
+	ut.Expect(t, Unified("a (registry)", "a (install)", "x\ny", "x\ny"), "")
+	ut.Expect(t, Unified("a (registry)", "a (install)", "", "x"), "--- a (registry)\n+++ a (install)\n@@ -0,0 +1,1 @@\n+x\n")
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	new := "1\n2a\n3\n4\n5\n6\n7\n8\n9\n10\n11b\n12"
+	ut.Expect(t, Unified("a (registry)", "a (install)", old, new), "--- a (registry)\n+++ a (install)\n"+
+		"@@ -1,5 +1,5 @@\n 1\n-2\n+2a\n 3\n 4\n 5\n"+
+		"@@ -8,5 +8,5 @@\n 8\n 9\n 10\n-11\n+11b\n 12\n")
+}
@@ -0,0 +1,157 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	//FormatJSON json格式,未指定格式标识的内容均为json
+	FormatJSON = "json"
+	//FormatYAML yaml格式,内容首行为 #!yaml
+	FormatYAML = "yaml"
+	//FormatTOML toml格式,内容首行为 #!toml
+	FormatTOML = "toml"
+)
+
+//formatHeader 格式标识前缀,yaml与toml均将#开头的行视为注释
+const formatHeader = "#!"
+
+//GetFormat 根据首行的格式标识获取内容格式
+func GetFormat(data []byte) string {
+	format, _ := splitFormat(data)
+	return format
+}
+
+//WithFormat 为yaml或toml内容增加格式标识
+func WithFormat(format string, content string) string {
+	if format == FormatJSON || format == "" {
+		return content
+	}
+	return fmt.Sprintf("%s%s\n%s", formatHeader, format, content)
+}
+
+//ToJSON 将yaml或toml内容转换为json,json内容去除格式标识后返回
+func ToJSON(data []byte) ([]byte, error) {
+	format, body := splitFormat(data)
+	if format == FormatJSON {
+		return body, nil
+	}
+	v, err := unmarshalFormat(format, body)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+//Convert 将内容转换为指定格式,json内容格式化输出,yaml与toml内容包含格式标识
+func Convert(data []byte, format string) ([]byte, error) {
+	src, body := splitFormat(data)
+	if src == format && format != FormatJSON {
+		return data, nil
+	}
+	v, err := unmarshalFormat(src, body)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(v, "", "    ")
+	case FormatYAML:
+		buff, err := yaml.Marshal(normalize(v, true))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(WithFormat(FormatYAML, string(buff))), nil
+	case FormatTOML:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("toml格式仅支持对象")
+		}
+		buff := bytes.NewBufferString("")
+		if err := toml.NewEncoder(buff).Encode(normalize(m, true)); err != nil {
+			return nil, err
+		}
+		return []byte(WithFormat(FormatTOML, buff.String())), nil
+	default:
+		return nil, fmt.Errorf("不支持的配置格式:%s", format)
+	}
+}
+
+//splitFormat 获取格式标识与去除标识后的内容
+func splitFormat(data []byte) (string, []byte) {
+	if !bytes.HasPrefix(data, []byte(formatHeader)) {
+		return FormatJSON, data
+	}
+	line, body := data, []byte{}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line, body = data[:i], data[i+1:]
+	}
+	switch f := string(bytes.TrimSpace(line[len(formatHeader):])); f {
+	case FormatYAML, FormatTOML, FormatJSON:
+		return f, body
+	}
+	return FormatJSON, data
+}
+
+func unmarshalFormat(format string, data []byte) (interface{}, error) {
+	var v interface{}
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("yaml格式错误:%v", err)
+		}
+	case FormatTOML:
+		m := make(map[string]interface{})
+		if _, err := toml.Decode(string(data), &m); err != nil {
+			return nil, fmt.Errorf("toml格式错误:%v", err)
+		}
+		v = m
+	default:
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+	}
+	return normalize(v, false), nil
+}
+
+//normalize 将yaml解析的map[interface{}]interface{}转换为map[string]interface{},
+//toInt为true时将整数值的浮点数转换为整数,避免输出为8090.0
+func normalize(v interface{}, toInt bool) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprint(k)] = normalize(v, toInt)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = normalize(v, toInt)
+		}
+		return m
+	case []map[string]interface{}:
+		l := make([]interface{}, 0, len(t))
+		for _, v := range t {
+			l = append(l, normalize(v, toInt))
+		}
+		return l
+	case []interface{}:
+		l := make([]interface{}, 0, len(t))
+		for _, v := range t {
+			l = append(l, normalize(v, toInt))
+		}
+		return l
+	case float64:
+		if toInt && t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return int64(t)
+		}
+	}
+	return v
+}
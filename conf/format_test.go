@@ -0,0 +1,43 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/sereiner/library/ut"
+)
+
+func TestFormat(t *testing.T) {
+	yml := WithFormat(FormatYAML, "address: \":8090\"\nport: 8090\nhosts:\n  - a\n  - b\n")
+	ut.Expect(t, GetFormat([]byte(yml)), FormatYAML)
+	c, err := NewJSONConf([]byte(yml), 1)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, c.GetString("address"), ":8090")
+	ut.Expect(t, c.GetInt("port"), 8090)
+	ut.Expect(t, string(c.GetRaw()), `{"address":":8090","hosts":["a","b"],"port":8090}`)
+
+	tml := WithFormat(FormatTOML, "address = \":8090\"\n[db]\nprovider = \"ora\"\n[[tasks]]\ncron = \"@every 1m\"\n")
+	c, err = NewJSONConf([]byte(tml), 1)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(c.GetRaw()), `{"address":":8090","db":{"provider":"ora"},"tasks":[{"cron":"@every 1m"}]}`)
+
+	_, err = NewJSONConf([]byte(WithFormat(FormatYAML, "a: [")), 1)
+	ut.Refute(t, err, nil)
+
+	c, err = NewJSONConf([]byte("#!json\n{\"a\":1}"), 1)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, c.GetInt("a"), 1)
+	ut.Expect(t, string(c.GetRaw()), `{"a":1}`)
+
+	buff, err := Convert([]byte(`{"address":":8090","port":8090}`), FormatYAML)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(buff), "#!yaml\naddress: :8090\nport: 8090\n")
+	buff, err = Convert([]byte(`{"address":":8090","port":8090,"tasks":[{"cron":"@every 1m"}]}`), FormatTOML)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(buff), "#!toml\naddress = \":8090\"\nport = 8090\n\n[[tasks]]\n  cron = \"@every 1m\"\n")
+	buff, err = Convert([]byte(tml), FormatJSON)
+	ut.Expect(t, err, nil)
+	c, _ = NewJSONConf(buff, 1)
+	ut.Expect(t, c.GetString("address"), ":8090")
+	_, err = Convert([]byte(`[1,2]`), FormatTOML)
+	ut.Refute(t, err, nil)
+}
@@ -0,0 +1,296 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/registry"
+)
+
+//MaxRevisions 每个节点保留的最大历史版本数
+var MaxRevisions = 50
+
+//historyNode 历史记录根节点名称,位于/plat/.history
+const historyNode = ".history"
+
+//UnknownUser 通过注册中心客户端等其它方式修改配置时,无法获取操作人
+const UnknownUser = "unknown"
+
+//maxRetry 多个服务器同时记录历史版本时的最大重试次数
+const maxRetry = 3
+
+const (
+	//ActionApply 服务器应用配置
+	ActionApply = "apply"
+	//ActionRollback 回滚配置
+	ActionRollback = "rollback"
+	//ActionInstall 安装配置
+	ActionInstall = "install"
+)
+
+//Record 配置历史记录,Data为注册中心中的原始内容
+type Record struct {
+	Path     string    `json:"path"`
+	Revision int       `json:"revision"`
+	Version  int32     `json:"version"`
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Action   string    `json:"action"`
+	Data     string    `json:"data"`
+}
+
+//History 配置历史,保存在注册中心/plat/.history节点下
+type History struct {
+	registry registry.IRegistry
+	user     string
+}
+
+//New 构建配置历史,用于命令行修改配置,操作人为当前用户
+func New(rgst registry.IRegistry) *History {
+	return &History{registry: rgst, user: GetOperator()}
+}
+
+//NewApplier 构建服务器使用的配置历史,服务器应用配置时无法获取修改配置的操作人;
+//通过命令行修改的配置已记录操作人,服务器应用相同内容时不再记录
+func NewApplier(rgst registry.IRegistry) *History {
+	return &History{registry: rgst, user: UnknownUser}
+}
+
+//GetOperator 获取当前操作人,格式为 用户名@主机名
+func GetOperator() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s@%s", name, host)
+}
+
+//RecordConf 记录服务器应用的主配置,子配置与var配置,data,version为主配置的原始内容与版本号
+func (h *History) RecordConf(c conf.IServerConf, data []byte, version int32) ([]*Record, error) {
+	records := make([]*Record, 0, 1)
+	r, err := h.Record(c.GetMainConfPath(), data, version, ActionApply)
+	if err != nil {
+		return records, err
+	}
+	if r != nil {
+		records = append(records, r)
+	}
+	paths := make([]string, 0, 4)
+	c.IterSubConf(func(k string, cn *conf.JSONConf) bool {
+		paths = append(paths, registry.Join(c.GetMainConfPath(), k))
+		return true
+	})
+	c.IterVarConf(func(k string, cn *conf.JSONConf) bool {
+		paths = append(paths, registry.Join("/", c.GetPlatName(), "var", k))
+		return true
+	})
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, version, err := h.registry.GetValue(path)
+		if err != nil {
+			return records, err
+		}
+		r, err := h.Record(path, data, version, ActionApply)
+		if err != nil {
+			return records, err
+		}
+		if r != nil {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+//Record 记录节点内容,与最新历史记录相同时不记录并返回nil
+func (h *History) Record(path string, data []byte, version int32, action string) (*Record, error) {
+	root, err := getHistoryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < maxRetry; i++ {
+		r, ok, err := h.record(path, root, data, version, action)
+		if err != nil || ok {
+			return r, err
+		}
+	}
+	return nil, fmt.Errorf("保存配置历史失败:%s(版本号已被占用)", path)
+}
+
+//record 保存为最新历史版本,版本号已被其它服务器占用时返回false
+func (h *History) record(path string, root string, data []byte, version int32, action string) (*Record, bool, error) {
+	revisions, err := h.getRevisions(root)
+	if err != nil {
+		return nil, false, err
+	}
+	last := 0
+	if len(revisions) > 0 {
+		last = revisions[len(revisions)-1]
+		r, err := h.get(path, root, last)
+		if err != nil {
+			return nil, false, err
+		}
+		if r.Data == string(data) {
+			return nil, true, nil
+		}
+	}
+	r := &Record{
+		Path:     path,
+		Revision: last + 1,
+		Version:  version,
+		Time:     time.Now(),
+		User:     h.user,
+		Action:   action,
+		Data:     string(data),
+	}
+	buff, err := json.Marshal(r)
+	if err != nil {
+		return nil, false, err
+	}
+	rpath := registry.Join(root, revisionName(r.Revision))
+	if b, err := h.registry.Exists(rpath); err != nil || b {
+		return nil, false, err
+	}
+	if err = h.registry.CreatePersistentNode(rpath, string(buff)); err != nil {
+		if b, _ := h.registry.Exists(rpath); b {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("保存配置历史失败:%s(%v)", path, err)
+	}
+
+	//删除超出保留数量的历史版本
+	revisions = append(revisions, r.Revision)
+	for i := 0; i < len(revisions)-MaxRevisions; i++ {
+		h.registry.Delete(registry.Join(root, revisionName(revisions[i])))
+	}
+	return r, true, nil
+}
+
+//List 获取节点的历史记录,按版本升序排列
+func (h *History) List(path string) ([]*Record, error) {
+	root, err := getHistoryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := h.getRevisions(root)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(revisions))
+	for _, rev := range revisions {
+		r, err := h.get(path, root, rev)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+//Get 获取节点的指定版本
+func (h *History) Get(path string, revision int) (*Record, error) {
+	root, err := getHistoryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return h.get(path, root, revision)
+}
+
+//Nodes 获取平台下有历史记录的节点
+func (h *History) Nodes(platName string) ([]string, error) {
+	root := registry.Join("/", platName, historyNode)
+	if b, err := h.registry.Exists(root); err != nil || !b {
+		return nil, err
+	}
+	children, _, err := h.registry.GetChildren(root)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(children))
+	for _, c := range children {
+		rel, err := url.QueryUnescape(c)
+		if err != nil {
+			continue
+		}
+		paths = append(paths, registry.Join("/", platName, rel))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+//Rollback 将节点内容回滚到指定版本,并记录为新的历史版本
+func (h *History) Rollback(path string, revision int) (*Record, error) {
+	r, err := h.Get(path, revision)
+	if err != nil {
+		return nil, err
+	}
+	_, version, err := h.registry.GetValue(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = h.registry.Update(path, r.Data, version); err != nil {
+		return nil, err
+	}
+	_, version, _ = h.registry.GetValue(path)
+	return h.Record(path, []byte(r.Data), version, ActionRollback)
+}
+
+//Touch 以原内容更新节点,触发监控该节点的服务器重新加载配置
+func Touch(rgst registry.IRegistry, path string) error {
+	data, version, err := rgst.GetValue(path)
+	if err != nil {
+		return err
+	}
+	return rgst.Update(path, string(data), version)
+}
+
+func (h *History) get(path string, root string, revision int) (*Record, error) {
+	data, _, err := h.registry.GetValue(registry.Join(root, revisionName(revision)))
+	if err != nil {
+		return nil, fmt.Errorf("获取%s的历史版本%d失败:%v", path, revision, err)
+	}
+	r := &Record{}
+	if err = json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s的历史版本%d格式错误:%v", path, revision, err)
+	}
+	return r, nil
+}
+
+func (h *History) getRevisions(root string) ([]int, error) {
+	if b, err := h.registry.Exists(root); err != nil || !b {
+		return nil, err
+	}
+	children, _, err := h.registry.GetChildren(root)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]int, 0, len(children))
+	for _, c := range children {
+		if rev, err := strconv.Atoi(c); err == nil {
+			revisions = append(revisions, rev)
+		}
+	}
+	sort.Ints(revisions)
+	return revisions, nil
+}
+
+//getHistoryPath 获取节点的历史记录路径 /plat/.history/转义后的相对路径
+func getHistoryPath(path string) (string, error) {
+	sections := strings.SplitN(strings.Trim(path, "/"), "/", 2)
+	if len(sections) != 2 || sections[0] == "" || sections[1] == "" {
+		return "", fmt.Errorf("节点路径错误:%s", path)
+	}
+	return registry.Join("/", sections[0], historyNode, url.QueryEscape(sections[1])), nil
+}
+
+func revisionName(revision int) string {
+	return fmt.Sprintf("%010d", revision)
+}
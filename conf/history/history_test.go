@@ -0,0 +1,84 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func TestHistory(t *testing.T) {
+	rgst := mem.New("TestHistory")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+	rgst.CreatePersistentNode("/test/order/api/t/conf/app", `{"name":"v1"}`)
+	rgst.CreatePersistentNode("/test/var/db/db", `{"provider":"ora"}`)
+	h := New(rgst)
+
+	record := func() []*Record {
+		data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+		c, err := conf.NewServerConf("/test/order/api/t/conf", data, version, rgst)
+		ut.Expect(t, err, nil)
+		records, err := h.RecordConf(c, data, version)
+		ut.Expect(t, err, nil)
+		return records
+	}
+	ut.Expect(t, len(record()), 3)
+
+	//内容未变化时不记录
+	ut.Expect(t, len(record()), 0)
+
+	_, version, _ := rgst.GetValue("/test/order/api/t/conf/app")
+	rgst.Update("/test/order/api/t/conf/app", `{"name":"v2"}`, version)
+	records := record()
+	ut.Expect(t, len(records), 1)
+	ut.Expect(t, records[0].Path, "/test/order/api/t/conf/app")
+	ut.Expect(t, records[0].Revision, 2)
+
+	paths, err := h.Nodes("test")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, paths, []string{"/test/order/api/t/conf", "/test/order/api/t/conf/app", "/test/var/db/db"})
+
+	r, err := h.Rollback("/test/order/api/t/conf/app", 1)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, r.Revision, 3)
+	ut.Expect(t, r.Action, ActionRollback)
+	data, _, _ := rgst.GetValue("/test/order/api/t/conf/app")
+	ut.Expect(t, string(data), `{"name":"v1"}`)
+	list, err := h.List("/test/order/api/t/conf/app")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, len(list), 3)
+
+	_, err = h.Rollback("/test/order/api/t/conf/app", 9)
+	ut.Refute(t, err, nil)
+}
+
+func TestHistoryCluster(t *testing.T) {
+	rgst := mem.New("TestHistoryCluster")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+
+	//命令行修改配置时记录操作人,服务器应用相同内容时不再记录
+	data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+	r, err := New(rgst).Record("/test/order/api/t/conf", data, version, ActionInstall)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, r.User, GetOperator())
+
+	//集群中的多个服务器同时记录时不报错
+	rgst.Update("/test/order/api/t/conf", `{"address":":9090"}`, -1)
+	data, version, _ = rgst.GetValue("/test/order/api/t/conf")
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, err := NewApplier(rgst).Record("/test/order/api/t/conf", data, version, ActionApply)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		ut.Expect(t, <-errs, nil)
+	}
+	list, _ := New(rgst).List("/test/order/api/t/conf")
+	ut.Expect(t, len(list), 2)
+	ut.Expect(t, list[1].User, UnknownUser)
+}
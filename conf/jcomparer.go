@@ -15,8 +15,10 @@ func NewJSONComparer(Oconf IConf, Nconf IConf) *JSONComparer {
 		Nconf: Nconf,
 	}
 }
+//IsChanged 检查版本号或签名是否发生变化,签名可反映密钥引用更新
 func (s *JSONComparer) IsChanged() bool {
-	return s.Oconf == nil || reflect.ValueOf(s.Oconf).IsNil() || s.Oconf.GetVersion() != s.Nconf.GetVersion()
+	return s.Oconf == nil || reflect.ValueOf(s.Oconf).IsNil() ||
+		s.Oconf.GetVersion() != s.Nconf.GetVersion() || s.Oconf.GetSignature() != s.Nconf.GetSignature()
 }
 
 //IsValueChanged 检查值是否发生变化
@@ -3,8 +3,10 @@ package conf
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sereiner/library/security/md5"
 )
@@ -16,6 +18,10 @@ type IConf interface {
 	GetInt(key string, def ...int) int
 	GetArray(key string, def ...interface{}) (r []interface{})
 	GetBool(key string, def ...bool) (r bool)
+	GetFloat(key string, def ...float64) float64
+	GetDuration(key string, def ...time.Duration) time.Duration
+	GetStringMap(key string, def ...map[string]interface{}) map[string]interface{}
+	GetStringSlice(key string, def ...string) []string
 	GeJSON(section string) (r []byte, version int32, err error)
 	GetSection(section string) (c *JSONConf, err error)
 	HasSection(section string) bool
@@ -47,8 +53,11 @@ func NewJSONConfByMap(data map[string]interface{}, version int32) (c *JSONConf,
 	return c, nil
 }
 
-//NewJSONConf 初始化JsonConf
+//NewJSONConf 初始化JsonConf,首行包含格式标识(#!yaml,#!toml)的内容转换为json后解析
 func NewJSONConf(message []byte, version int32) (c *JSONConf, err error) {
+	if message, err = ToJSON(message); err != nil {
+		return nil, err
+	}
 	c = &JSONConf{
 		raw:       json.RawMessage(message),
 		signature: md5.EncryptBytes(message),
@@ -79,29 +88,71 @@ func (j *JSONConf) GetRaw() []byte {
 	return j.raw
 }
 
-//GetString 获取字符串
-func (j *JSONConf) GetString(key string, def ...string) (r string) {
-	if val, ok := j.data[key]; ok {
-		switch v := val.(type) {
-		case string:
-			return v
+//get 获取节点值,key为顶级键名或以.分隔的路径,如redis.pool.size,hosts[0],hosts.0
+func (j *JSONConf) get(key string) (interface{}, bool) {
+	if v, ok := j.data[key]; ok {
+		return v, true
+	}
+	if !strings.ContainsAny(key, ".[") {
+		return nil, false
+	}
+	path := strings.Replace(strings.Replace(key, "[", ".", -1), "]", "", -1)
+	var current interface{} = j.data
+	for _, name := range strings.Split(path, ".") {
+		switch v := current.(type) {
 		case map[string]interface{}:
-			buffer, _ := json.Marshal(val)
-			return string(buffer)
+			val, ok := v[name]
+			if !ok {
+				return nil, false
+			}
+			current = val
+		case []interface{}:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
 		default:
-			return fmt.Sprint(val)
+			return nil, false
 		}
 	}
+	return current, true
+}
+
+//GetString 获取字符串
+func (j *JSONConf) GetString(key string, def ...string) (r string) {
+	if val, ok := j.get(key); ok {
+		return toString(val)
+	}
 	if len(def) > 0 {
 		return def[0]
 	}
 	return ""
 }
 
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		buffer, _ := json.Marshal(val)
+		return string(buffer)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
 //GetInt 获取数字
 func (j *JSONConf) GetInt(key string, def ...int) int {
-	if v, err := strconv.Atoi(j.GetString(key)); err == nil {
-		return v
+	if val, ok := j.get(key); ok {
+		if v, ok := val.(float64); ok && v == math.Trunc(v) {
+			return int(v)
+		}
+		if v, err := strconv.Atoi(toString(val)); err == nil {
+			return v
+		}
 	}
 	if len(def) > 0 {
 		return def[0]
@@ -124,18 +175,88 @@ func (j *JSONConf) GetStrings(key string, def ...string) (r []string) {
 
 //GetArray 获取数组对象
 func (j *JSONConf) GetArray(key string, def ...interface{}) (r []interface{}) {
-	if _, ok := j.data[key]; !ok {
+	val, ok := j.get(key)
+	if !ok {
 		if len(def) > 0 {
 			return def
 		}
 		return nil
 	}
-	if r, ok := j.data[key].([]interface{}); ok {
+	if r, ok := val.([]interface{}); ok {
 		return r
 	}
 	return nil
 }
 
+//GetFloat 获取浮点数
+func (j *JSONConf) GetFloat(key string, def ...float64) float64 {
+	if val, ok := j.get(key); ok {
+		if v, ok := val.(float64); ok {
+			return v
+		}
+		if v, err := strconv.ParseFloat(toString(val), 64); err == nil {
+			return v
+		}
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+//GetDuration 获取时长,字符串按time.ParseDuration解析(如5s,1m30s),数字按秒处理
+func (j *JSONConf) GetDuration(key string, def ...time.Duration) time.Duration {
+	if val, ok := j.get(key); ok {
+		if v, err := time.ParseDuration(toString(val)); err == nil {
+			return v
+		}
+		if v, err := strconv.ParseFloat(toString(val), 64); err == nil {
+			return time.Duration(v * float64(time.Second))
+		}
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+//GetStringMap 获取对象
+func (j *JSONConf) GetStringMap(key string, def ...map[string]interface{}) map[string]interface{} {
+	if val, ok := j.get(key); ok {
+		if m, ok := val.(map[string]interface{}); ok {
+			return m
+		}
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return nil
+}
+
+//GetStringSlice 获取字符串数组,数组元素转换为字符串,字符串值按;分隔
+func (j *JSONConf) GetStringSlice(key string, def ...string) []string {
+	if val, ok := j.get(key); ok {
+		switch v := val.(type) {
+		case []interface{}:
+			r := make([]string, 0, len(v))
+			for _, item := range v {
+				r = append(r, toString(item))
+			}
+			return r
+		case []string:
+			return v
+		case string:
+			if v != "" {
+				return strings.Split(v, ";")
+			}
+		}
+	}
+	if len(def) > 0 {
+		return def
+	}
+	return nil
+}
+
 //GetBool 获取bool类型值
 func (j *JSONConf) GetBool(key string, def ...bool) (r bool) {
 	if val := j.GetString(key); val != "" {
@@ -151,11 +272,11 @@ func (j *JSONConf) GetBool(key string, def ...bool) (r bool) {
 
 //GeJSON 获取section原始JSON串
 func (j *JSONConf) GeJSON(section string) (r []byte, version int32, err error) {
-	if v, ok := j.data[section]; !ok || v == nil {
+	val, ok := j.get(section)
+	if !ok || val == nil {
 		err = fmt.Errorf("节点:%s不存在或值为空", section)
 		return
 	}
-	val := j.data[section]
 	buffer, err := json.Marshal(val)
 	if err != nil {
 		return nil, 0, err
@@ -165,17 +286,19 @@ func (j *JSONConf) GeJSON(section string) (r []byte, version int32, err error) {
 
 //HasSection 是否存在节点
 func (j *JSONConf) HasSection(section string) bool {
-	_, ok := j.data[section].(map[string]interface{})
+	val, _ := j.get(section)
+	_, ok := val.(map[string]interface{})
 	return ok
 }
 
 //GetSection 指定节点名称获取JSONConf
 func (j *JSONConf) GetSection(section string) (c *JSONConf, err error) {
-	if v, ok := j.data[section]; !ok || v == nil {
+	val, ok := j.get(section)
+	if !ok || val == nil {
 		err = fmt.Errorf("节点:%s不存在或值为空", section)
 		return
 	}
-	if data, ok := j.data[section].(map[string]interface{}); ok {
+	if data, ok := val.(map[string]interface{}); ok {
 		return NewJSONConfByMap(data, j.version)
 	}
 	err = fmt.Errorf("节点:%s不是有效的json对象", section)
@@ -2,6 +2,7 @@ package conf
 
 import (
 	"testing"
+	"time"
 
 	"github.com/sereiner/library/ut"
 )
@@ -105,7 +106,8 @@ func TestJsonConfInt(t *testing.T) {
 			{input: `{"a":"c/b"}`, field: "a", def: 6, expected: 6},
 			{input: `{"a":100}`, field: "a", def: 6, expected: 100},
 			{input: `{"a":-100}`, field: "a", def: 6, expected: -100},
-			{input: `{"a":2147483646}`, field: "a", def: 6, expected: 6},
+			{input: `{"a":2147483646}`, field: "a", def: 6, expected: 2147483646},
+			{input: `{"a":1.5}`, field: "a", def: 6, expected: 6},
 		}
 
 	for _, tb := range tbs {
@@ -152,3 +154,39 @@ func TestJsonConfSecion(t *testing.T) {
 	ut.Expect(t, b.GetString("c"), "100")
 	ut.Expect(t, b.GetInt("c"), 100)
 }
+
+func TestJsonConfPath(t *testing.T) {
+	jc, _ := NewJSONConf([]byte(`{"redis":{"pool":{"size":10},"addrs":["192.168.0.1:6379","192.168.0.2:6379"]},"a.b":"c","id":10000000}`), 1)
+	ut.Expect(t, jc.GetInt("redis.pool.size"), 10)
+	ut.Expect(t, jc.GetString("redis.addrs[1]"), "192.168.0.2:6379")
+	ut.Expect(t, jc.GetString("redis.addrs.0"), "192.168.0.1:6379")
+	ut.Expect(t, jc.GetString("redis.addrs[2]", "x"), "x")
+	ut.Expect(t, jc.GetString("redis.pool.size.x", "x"), "x")
+	ut.Expect(t, jc.GetString("a.b"), "c")
+	ut.Expect(t, jc.GetInt("id"), 10000000)
+	ut.Expect(t, jc.GetString("id"), "10000000")
+	ut.Expect(t, jc.HasSection("redis.pool"), true)
+	c, err := jc.GetSection("redis.pool")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, c.GetInt("size"), 10)
+}
+
+func TestJsonConfTyped(t *testing.T) {
+	jc, _ := NewJSONConf([]byte(`{"rate":0.5,"srate":"1.5","timeout":"5s","wait":3,"int":1.5,"header":{"a":"b"},"hosts":["a",1],"exts":".js;.css"}`), 1)
+	ut.Expect(t, jc.GetFloat("rate"), 0.5)
+	ut.Expect(t, jc.GetFloat("srate"), 1.5)
+	ut.Expect(t, jc.GetFloat("x", 2.5), 2.5)
+	ut.Expect(t, jc.GetInt("int", 9), 9)
+	ut.Expect(t, jc.GetDuration("timeout"), 5*time.Second)
+	ut.Expect(t, jc.GetDuration("wait"), 3*time.Second)
+	ut.Expect(t, jc.GetDuration("header", time.Minute), time.Minute)
+	ut.Expect(t, jc.GetStringMap("header"), map[string]interface{}{"a": "b"})
+	ut.Expect(t, jc.GetStringMap("x", map[string]interface{}{}), map[string]interface{}{})
+	ut.Expect(t, jc.GetStringSlice("hosts"), []string{"a", "1"})
+	ut.Expect(t, jc.GetStringSlice("exts"), []string{".js", ".css"})
+	ut.Expect(t, jc.GetStringSlice("x", "y"), []string{"y"})
+
+	jc, _ = NewJSONConfByMap(map[string]interface{}{"port": 8090, "hosts": []string{"a"}}, 1)
+	ut.Expect(t, jc.GetInt("port"), 8090)
+	ut.Expect(t, jc.GetStringSlice("hosts"), []string{"a"})
+}
@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/registry"
+	"github.com/zkfy/cron"
+)
+
+//Issue 配置检查发现的问题
+type Issue struct {
+	Path    string
+	Message string
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("%s:%s", i.Path, i.Message)
+}
+
+//mainConfs 服务器类型对应的主配置结构
+var mainConfs = map[string]func() interface{}{
+	"api":  func() interface{} { return &conf.APIServerConf{} },
+	"web":  func() interface{} { return &conf.WebServerConf{} },
+	"rpc":  func() interface{} { return &conf.RPCServerConf{} },
+	"ws":   func() interface{} { return &conf.WSServerConf{} },
+	"mqc":  func() interface{} { return &conf.MQCServerConf{} },
+	"cron": func() interface{} { return &conf.CronServerConf{} },
+}
+
+//varRef var节点引用,格式为 var/类型/名称 或 /平台/var/类型/名称
+var varRef = regexp.MustCompile(`^(/[^/]+)?/?var/[^/]+/[^/]+$`)
+
+//installParam 未替换的安装参数,格式为 #参数名
+var installParam = regexp.MustCompile(`^#[A-Za-z_][\w.]*$`)
+
+//Lint 检查服务器配置,services为服务器可提供的服务,为nil时不检查路由服务是否存在
+func Lint(c conf.IServerConf, services []string) []*Issue {
+	l := &linter{conf: c, services: services, issues: make([]*Issue, 0, 1)}
+	l.checkMain()
+	c.IterSubConf(func(k string, cn *conf.JSONConf) bool {
+		l.checkSub(k, cn)
+		return true
+	})
+	c.IterVarConf(func(k string, cn *conf.JSONConf) bool {
+		path := registry.Join("/", c.GetPlatName(), "var", k)
+		l.add(path, conf.ValidateConf(registry.Join("var", k), cn))
+		l.checkRefs(path, cn)
+		return true
+	})
+	sort.SliceStable(l.issues, func(i, j int) bool { return l.issues[i].Path < l.issues[j].Path })
+	return l.issues
+}
+
+type linter struct {
+	conf     conf.IServerConf
+	services []string
+	issues   []*Issue
+}
+
+func (l *linter) add(path string, err error) {
+	if err != nil {
+		l.issues = append(l.issues, &Issue{Path: path, Message: err.Error()})
+	}
+}
+
+func (l *linter) addf(path string, format string, args ...interface{}) {
+	l.issues = append(l.issues, &Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) checkMain() {
+	path := l.conf.GetMainConfPath()
+	if f, ok := mainConfs[l.conf.GetServerType()]; ok {
+		v := f()
+		if err := l.conf.Unmarshal(v); err != nil {
+			l.add(path, err)
+		} else {
+			//restart为有效状态,仅结构体校验规则未包含
+			if s := reflect.ValueOf(v).Elem().FieldByName("Status"); s.IsValid() && s.String() == "restart" {
+				s.SetString("")
+			}
+			if b, err := govalidator.ValidateStruct(v); !b && err != nil {
+				l.add(path, err)
+			}
+		}
+	}
+	l.checkRefs(path, l.conf)
+}
+
+func (l *linter) checkSub(name string, cn *conf.JSONConf) {
+	path := registry.Join(l.conf.GetMainConfPath(), name)
+	l.add(path, conf.ValidateConf(name, cn))
+	l.checkRefs(path, cn)
+	switch name {
+	case "router":
+		var routers conf.Routers
+		if err := cn.Unmarshal(&routers); err == nil {
+			for i, r := range routers.Routers {
+				l.checkService(path, i, r.Engine, r.Service)
+			}
+		}
+	case "queue":
+		var queues conf.Queues
+		if err := cn.Unmarshal(&queues); err == nil {
+			for i, q := range queues.Queues {
+				l.checkService(path, i, q.Engine, q.Service)
+			}
+		}
+	case "task":
+		var tasks conf.Tasks
+		if err := cn.Unmarshal(&tasks); err == nil {
+			for i, t := range tasks.Tasks {
+				if _, err := cron.ParseStandard(t.Cron); err != nil {
+					l.addf(path, "第%d项:cron表达式(%s)有误:%v", i+1, t.Cron, err)
+				}
+				l.checkService(path, i, t.Engine, t.Service)
+			}
+		}
+	}
+}
+
+//checkService 检查服务是否已注册,RPC引擎的服务由远程服务器提供,包含@的服务名为模板,不检查
+func (l *linter) checkService(path string, i int, engine string, service string) {
+	if l.services == nil || service == "" || strings.EqualFold(engine, "rpc") || strings.Contains(service, "@") {
+		return
+	}
+	name := "/" + strings.Trim(service, "/")
+	for _, s := range l.services {
+		s = "/" + strings.Trim(s, "/")
+		if s == name || strings.HasPrefix(name, s+"/") {
+			return
+		}
+	}
+	l.addf(path, "第%d项:服务%s未注册", i+1, service)
+}
+
+//checkRefs 检查引用的var节点是否存在,是否包含未替换的安装参数
+func (l *linter) checkRefs(path string, c conf.IConf) {
+	var v interface{}
+	if err := json.Unmarshal(c.GetRaw(), &v); err != nil {
+		return
+	}
+	walk(v, func(s string) {
+		switch {
+		case installParam.MatchString(s):
+			l.addf(path, "安装参数%s未设置", s)
+		case varRef.MatchString(s):
+			sections := strings.Split(strings.Trim(s, "/"), "/")
+			n := len(sections)
+			if n == 4 && sections[0] != l.conf.GetPlatName() {
+				return
+			}
+			if !l.conf.HasVarConf(sections[n-2], sections[n-1]) {
+				l.addf(path, "引用的var节点%s不存在", s)
+			}
+		}
+	})
+}
+
+//walk 遍历所有字符串值
+func walk(v interface{}, f func(string)) {
+	switch t := v.(type) {
+	case string:
+		f(t)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walk(t[k], f)
+		}
+	case []interface{}:
+		for _, item := range t {
+			walk(item, f)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func TestLint(t *testing.T) {
+	rgst := mem.New("TestLint")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/cron/t/conf", `{"status":"restart","cache":"var/cache/cache"}`)
+	rgst.CreatePersistentNode("/test/order/cron/t/conf/task", `{"tasks":[
+		{"cron":"@every 10s","service":"/order/query"},
+		{"cron":"* * *","service":"/order/pay"},
+		{"cron":"@every 1m","service":"/order/@name"},
+		{"cron":"@every 1m","service":"/order/remote","engine":"RPC"}]}`)
+	rgst.CreatePersistentNode("/test/var/db/db", `{"provider":"#db_provider","connString":"order/123456@orcl136","maxOpen":10,"maxIdle":10,"lifeTime":600}`)
+	data, version, _ := rgst.GetValue("/test/order/cron/t/conf")
+	c, err := conf.NewServerConf("/test/order/cron/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+
+	issues := Lint(c, []string{"/order"})
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+	ut.Expect(t, len(issues), 3)
+	ut.Expect(t, strings.Contains(messages[0], "var/cache/cache不存在"), true)
+	ut.Expect(t, strings.Contains(messages[1], "第2项:cron表达式(* * *)有误"), true)
+	ut.Expect(t, strings.Contains(messages[2], "#db_provider未设置"), true)
+
+	//服务未注册
+	issues = Lint(c, []string{"/order/query"})
+	ut.Expect(t, len(issues), 4)
+	ut.Expect(t, issues[2].Message, "第2项:服务/order/pay未注册")
+}
@@ -0,0 +1,163 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+//OverlayEnvPrefix 覆盖配置的环境变量前缀,格式为 PARROT_CONF__节点__键,多级键用__分隔,
+//节点名称中的/用_代替,如 PARROT_CONF__CONF__address,PARROT_CONF__VAR_CACHE_CACHE__addrs
+const OverlayEnvPrefix = "PARROT_CONF__"
+
+//OverlayFileEnv 本地覆盖配置文件路径环境变量,文件内容为 {"节点名称":{键:值}},支持json,yaml,toml
+const OverlayFileEnv = "parrot_conf_overlay"
+
+//overlayValue 覆盖的配置项
+type overlayValue struct {
+	path   []string
+	value  interface{}
+	source string
+}
+
+//Overlay 本地覆盖配置,节点名称为conf(所有主配置),服务器类型/conf(如api/conf),子节点名称或var/类型/名称
+type Overlay struct {
+	nodes map[string][]*overlayValue
+}
+
+//LoadOverlay 加载环境变量与本地文件中的覆盖配置,环境变量优先
+func LoadOverlay() (*Overlay, error) {
+	o := &Overlay{nodes: make(map[string][]*overlayValue)}
+	if path := os.Getenv(OverlayFileEnv); path != "" {
+		if err := o.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	o.loadEnv(os.Environ())
+	return o, nil
+}
+
+func (o *Overlay) loadFile(path string) error {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取覆盖配置文件%s失败:%v", path, err)
+	}
+	if buff, err = ToJSON(buff); err != nil {
+		return fmt.Errorf("覆盖配置文件%s格式错误:%v", path, err)
+	}
+	nodes := make(map[string]map[string]interface{})
+	if err = json.Unmarshal(buff, &nodes); err != nil {
+		return fmt.Errorf("覆盖配置文件%s格式错误:%v", path, err)
+	}
+	for node, values := range nodes {
+		name := normalizeNodeName(node)
+		flatten(nil, values, func(p []string, v interface{}) {
+			o.nodes[name] = append(o.nodes[name], &overlayValue{path: p, value: v, source: "file:" + path})
+		})
+	}
+	for _, values := range o.nodes {
+		sort.Slice(values, func(i, j int) bool { return strings.Join(values[i].path, ".") < strings.Join(values[j].path, ".") })
+	}
+	return nil
+}
+
+func (o *Overlay) loadEnv(environ []string) {
+	sort.Strings(environ)
+	for _, env := range environ {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], OverlayEnvPrefix) {
+			continue
+		}
+		sections := strings.Split(kv[0][len(OverlayEnvPrefix):], "__")
+		if len(sections) < 2 || sections[0] == "" {
+			continue
+		}
+		name := normalizeNodeName(sections[0])
+		o.nodes[name] = append(o.nodes[name], &overlayValue{path: sections[1:], value: parseOverlayValue(kv[1]), source: "env:" + kv[0]})
+	}
+}
+
+//Apply 将覆盖配置合并到节点内容,返回合并后的内容与被覆盖的键及来源
+func (o *Overlay) Apply(names []string, data []byte) ([]byte, map[string]string, error) {
+	values := make([]*overlayValue, 0, 1)
+	for _, name := range names {
+		values = append(values, o.nodes[normalizeNodeName(name)]...)
+	}
+	if len(values) == 0 {
+		return data, nil, nil
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, nil, err
+	}
+	sources := make(map[string]string)
+	for _, v := range values {
+		key := setPath(m, v.path, v.value)
+		sources[key] = v.source
+	}
+	buff, err := json.Marshal(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buff, sources, nil
+}
+
+//normalizeNodeName 节点名称转换为小写,/替换为_,便于与环境变量名称比较
+func normalizeNodeName(name string) string {
+	return strings.ToLower(strings.Replace(strings.Trim(name, "/"), "/", "_", -1))
+}
+
+//parseOverlayValue 可解析为json的值(数字,bool,数组,对象)按json处理,其它作为字符串
+func parseOverlayValue(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	return s
+}
+
+//flatten 将对象展开为叶子节点,数组作为叶子节点
+func flatten(prefix []string, v interface{}, f func([]string, interface{})) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		f(prefix, v)
+		return
+	}
+	for k, item := range m {
+		p := make([]string, len(prefix), len(prefix)+1)
+		copy(p, prefix)
+		flatten(append(p, k), item, f)
+	}
+}
+
+//setPath 按路径设置值,键名不区分大小写匹配已有的键,中间节点不是对象时替换为对象,返回实际设置的路径
+func setPath(m map[string]interface{}, path []string, value interface{}) string {
+	keys := make([]string, 0, len(path))
+	current := m
+	for i, name := range path {
+		key := name
+		if _, ok := current[key]; !ok {
+			for k := range current {
+				if strings.EqualFold(k, name) {
+					key = k
+					break
+				}
+			}
+		}
+		keys = append(keys, key)
+		if i == len(path)-1 {
+			current[key] = value
+			break
+		}
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	return strings.Join(keys, ".")
+}
@@ -0,0 +1,45 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func TestOverlay(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "parrot-overlay")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "overlay.yaml")
+	ioutil.WriteFile(path, []byte(WithFormat(FormatYAML, "api/conf:\n  address: \":9090\"\nvar/cache/cache:\n  addrs: [\"192.168.0.2:6379\"]\n  pool:\n    size: 20\n")), 0666)
+	os.Setenv(OverlayFileEnv, path)
+	defer os.Unsetenv(OverlayFileEnv)
+	os.Setenv(OverlayEnvPrefix+"CONF__HOST", "order.com")
+	defer os.Unsetenv(OverlayEnvPrefix + "CONF__HOST")
+	os.Setenv(OverlayEnvPrefix+"VAR_CACHE_CACHE__POOL__SIZE", "30")
+	defer os.Unsetenv(OverlayEnvPrefix + "VAR_CACHE_CACHE__POOL__SIZE")
+
+	rgst := mem.New("TestOverlay")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090","host":"a.com"}`)
+	rgst.CreatePersistentNode("/test/var/cache/cache", `{"proto":"redis","addrs":["192.168.0.1:6379"],"Pool":{"size":10}}`)
+	data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+	c, err := NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, c.GetString("address"), ":9090")
+	ut.Expect(t, c.GetString("host"), "order.com")
+	ut.Expect(t, c.GetOverlaySources("/test/order/api/t/conf"), map[string]string{
+		"address": "file:" + path,
+		"host":    "env:" + OverlayEnvPrefix + "CONF__HOST",
+	})
+
+	cache, err := c.GetVarConf("cache", "cache")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, cache.GetString("addrs[0]"), "192.168.0.2:6379")
+	ut.Expect(t, cache.GetInt("Pool.size"), 30)
+	ut.Expect(t, cache.GetString("proto"), "redis")
+	ut.Expect(t, c.GetOverlaySources("/test/var/cache/cache")["Pool.size"], "env:"+OverlayEnvPrefix+"VAR_CACHE_CACHE__POOL__SIZE")
+}
@@ -0,0 +1,178 @@
+package conf
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/asaskevich/govalidator"
+)
+
+//SchemaFunc 配置节点校验函数
+type SchemaFunc func(c *JSONConf) error
+
+var schemas = make(map[string]SchemaFunc)
+var schemaLock sync.RWMutex
+
+func init() {
+	RegisterSchema("router", validateRouter)
+	RegisterSchema("queue", validateQueue)
+	RegisterSchema("task", validateTask)
+	RegisterStructSchema("static", func() interface{} { return &Static{} })
+	RegisterSchema("metric", validateMetric)
+	RegisterSchema("tracing", validateTracing)
+	RegisterSchema("limiter", validateLimiter)
+	RegisterStructSchema("view", func() interface{} { return &View{} })
+	RegisterSchema("circuit", validateCircuit)
+	RegisterSchema("auth", validateAuth)
+	RegisterSchema("server", validateProto)
+	RegisterStructSchema("var/db/*", func() interface{} { return &DBConf{} })
+	RegisterStructSchema("var/rpc/*", func() interface{} { return &RPCConf{} })
+	RegisterSchema("var/cache/*", validateProto)
+	RegisterSchema("var/queue/*", validateProto)
+}
+
+//RegisterSchema 注册节点校验函数,name为子节点名称(如router)或var节点路径(如var/db/*),已存在时替换
+func RegisterSchema(name string, f SchemaFunc) {
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+	schemas[name] = f
+}
+
+//RegisterStructSchema 注册结构体校验,节点内容反序列化为newObject返回的对象后使用govalidator校验
+func RegisterStructSchema(name string, newObject func() interface{}) {
+	RegisterSchema(name, func(c *JSONConf) error {
+		v := newObject()
+		if err := c.Unmarshal(v); err != nil {
+			return err
+		}
+		if b, err := govalidator.ValidateStruct(v); !b {
+			return err
+		}
+		return nil
+	})
+}
+
+//GetSchema 获取节点校验函数,优先精确匹配,其次按通配符匹配
+func GetSchema(name string) (SchemaFunc, bool) {
+	schemaLock.RLock()
+	defer schemaLock.RUnlock()
+	if f, ok := schemas[name]; ok {
+		return f, true
+	}
+	patterns := make([]string, 0, len(schemas))
+	for k := range schemas {
+		patterns = append(patterns, k)
+	}
+	sort.Strings(patterns)
+	for _, k := range patterns {
+		if ok, _ := path.Match(k, name); ok {
+			return schemas[k], true
+		}
+	}
+	return nil, false
+}
+
+//ValidateConf 校验节点配置,未注册校验函数的节点不校验
+func ValidateConf(name string, c *JSONConf) error {
+	f, ok := GetSchema(name)
+	if !ok {
+		return nil
+	}
+	return f(c)
+}
+
+//validateRouter 逐项检查路由,govalidator不检查切片中的结构体
+func validateRouter(c *JSONConf) error {
+	var routers Routers
+	if err := c.Unmarshal(&routers); err != nil {
+		return err
+	}
+	for i, router := range routers.Routers {
+		if err := validateItem(i, router); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateQueue(c *JSONConf) error {
+	var queues Queues
+	if err := c.Unmarshal(&queues); err != nil {
+		return err
+	}
+	for i, queue := range queues.Queues {
+		if err := validateItem(i, queue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTask(c *JSONConf) error {
+	var tasks Tasks
+	if err := c.Unmarshal(&tasks); err != nil {
+		return err
+	}
+	for i, task := range tasks.Tasks {
+		if err := validateItem(i, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateItem(i int, v interface{}) error {
+	//Router包含interface字段,校验通过时也返回false,仅以错误判断
+	if _, err := govalidator.ValidateStruct(v); err != nil {
+		return fmt.Errorf("第%d项:%v", i+1, err)
+	}
+	return nil
+}
+
+func validateMetric(c *JSONConf) error {
+	var metric Metric
+	if err := c.Unmarshal(&metric); err != nil {
+		return err
+	}
+	return metric.Validate()
+}
+
+func validateCircuit(c *JSONConf) error {
+	var breaker CircuitBreaker
+	if err := c.Unmarshal(&breaker); err != nil {
+		return err
+	}
+	if breaker.Disable {
+		return nil
+	}
+	if b, err := govalidator.ValidateStruct(&breaker); !b {
+		return err
+	}
+	return nil
+}
+
+func validateAuth(c *JSONConf) error {
+	var auths Authes
+	if err := c.Unmarshal(&auths); err != nil {
+		return err
+	}
+	for k, auth := range auths {
+		if auth == nil {
+			return fmt.Errorf("%s未配置", k)
+		}
+		if b, err := govalidator.ValidateStruct(auth); !b {
+			return fmt.Errorf("%s:%v", k, err)
+		}
+	}
+	return nil
+}
+
+//validateProto 检查消息队列、缓存等配置是否指定了proto
+func validateProto(c *JSONConf) error {
+	if _, ok := c.data["proto"].(string); !ok || c.GetString("proto") == "" {
+		return fmt.Errorf("proto未配置")
+	}
+	return nil
+}
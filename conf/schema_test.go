@@ -0,0 +1,45 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func TestValidateConf(t *testing.T) {
+	c, _ := NewJSONConf([]byte(`{"routers":[{"name":"/order","service":"/order","action":["GET"]}]}`), 1)
+	ut.Expect(t, ValidateConf("router", c), nil)
+	c, _ = NewJSONConf([]byte(`{"routers":[{"name":"/order","action":["GET"]}]}`), 1)
+	ut.Refute(t, ValidateConf("router", c), nil)
+
+	c, _ = NewJSONConf([]byte(`{"provider":"ora","connString":"order/123456@orcl136","maxOpen":10,"maxIdle":10,"lifeTime":600}`), 1)
+	ut.Expect(t, ValidateConf("var/db/db", c), nil)
+	c, _ = NewJSONConf([]byte(`{"provider":"ora"}`), 1)
+	ut.Refute(t, ValidateConf("var/db/db", c), nil)
+	c, _ = NewJSONConf([]byte(`{"addrs":["192.168.0.1:6379"]}`), 1)
+	ut.Refute(t, ValidateConf("var/cache/cache", c), nil)
+
+	//未注册的节点不校验
+	ut.Expect(t, ValidateConf("app", c), nil)
+}
+
+func TestServerConfValidate(t *testing.T) {
+	rgst := mem.New("TestServerConfValidate")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+	rgst.CreatePersistentNode("/test/order/api/t/conf/circuit", `{"disable":true,"circuit-breakers":[{}]}`)
+	rgst.CreatePersistentNode("/test/var/queue/queue", `{"proto":"redis"}`)
+	data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+	c, err := NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, c.Validate(), nil)
+
+	rgst.CreatePersistentNode("/test/order/api/t/conf/auth", `{"jwt":{"name":"__jwt__","mode":"HS256"}}`)
+	c, err = NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+	err = c.Validate()
+	ut.Refute(t, err, nil)
+	ut.Expect(t, strings.HasPrefix(err.Error(), "/test/order/api/t/conf/auth配置有误:jwt:"), true)
+}
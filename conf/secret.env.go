@@ -0,0 +1,42 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+//EnvSecretName 环境变量密钥提供程序名称
+const EnvSecretName = "env"
+
+//SecretEnvPrefix 密钥环境变量前缀,如db/prod/password对应环境变量parrot_secret_db_prod_password
+const SecretEnvPrefix = "parrot_secret_"
+
+//EnvSecret 环境变量密钥提供程序
+type EnvSecret struct {
+}
+
+//Name 提供程序名称
+func (e *EnvSecret) Name() string {
+	return EnvSecretName
+}
+
+//Get 获取密钥对应的环境变量
+func (e *EnvSecret) Get(key string) (string, error) {
+	name := GetSecretEnvName(key)
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("环境变量%s未设置", name)
+	}
+	return v, nil
+}
+
+//GetSecretEnvName 获取密钥对应的环境变量名称,非字母数字的字符替换为下划线
+func GetSecretEnvName(key string) string {
+	return SecretEnvPrefix + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.Trim(key, "/"))
+}
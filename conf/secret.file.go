@@ -0,0 +1,57 @@
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//FileSecretName 文件密钥提供程序名称
+const FileSecretName = "file"
+
+//SecretDirEnv 密钥文件目录环境变量,未设置时使用 ~/.parrot/secrets
+const SecretDirEnv = "parrot_secret_dir"
+
+//FileSecret 文件密钥提供程序,每个密钥保存为目录下的一个文件,如db/prod/password对应 目录/db/prod/password
+type FileSecret struct {
+	dir string
+}
+
+//NewFileSecret 构建文件密钥提供程序,dir为空时使用环境变量parrot_secret_dir或默认目录
+func NewFileSecret(dir string) *FileSecret {
+	return &FileSecret{dir: dir}
+}
+
+//Name 提供程序名称
+func (f *FileSecret) Name() string {
+	return FileSecretName
+}
+
+//Get 读取密钥文件内容,去掉末尾换行
+func (f *FileSecret) Get(key string) (string, error) {
+	name := filepath.Clean("/" + key)
+	if name == "/" {
+		return "", fmt.Errorf("密钥名称不能为空")
+	}
+	buff, err := ioutil.ReadFile(filepath.Join(f.getDir(), name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buff), "\r\n"), nil
+}
+
+func (f *FileSecret) getDir() string {
+	if f.dir != "" {
+		return f.dir
+	}
+	if v := os.Getenv(SecretDirEnv); v != "" {
+		return v
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".parrot", "secrets")
+	}
+	return filepath.Join(home, ".parrot", "secrets")
+}
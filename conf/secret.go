@@ -0,0 +1,118 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sereiner/library/security/md5"
+)
+
+//SecretProviderEnv 默认密钥提供程序环境变量,未设置时使用file
+const SecretProviderEnv = "parrot_secret_provider"
+
+//ISecretProvider 密钥提供程序,用于解析配置中的 ${secret:名称} 引用
+type ISecretProvider interface {
+	//Name 提供程序名称
+	Name() string
+	//Get 获取密钥值
+	Get(key string) (string, error)
+}
+
+var secretProviders = make(map[string]ISecretProvider)
+var secretLock sync.RWMutex
+
+//secretPattern 密钥引用格式 ${secret:名称} 或 ${secret:提供程序:名称}
+var secretPattern = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+func init() {
+	RegisterSecretProvider(NewFileSecret(""))
+	RegisterSecretProvider(&EnvSecret{})
+	RegisterSecretProvider(NewVaultSecret(nil))
+}
+
+//RegisterSecretProvider 注册密钥提供程序,已存在的同名提供程序将被替换
+func RegisterSecretProvider(p ISecretProvider) {
+	secretLock.Lock()
+	defer secretLock.Unlock()
+	secretProviders[p.Name()] = p
+}
+
+func getSecretProvider(name string) (ISecretProvider, bool) {
+	secretLock.RLock()
+	defer secretLock.RUnlock()
+	p, ok := secretProviders[name]
+	return p, ok
+}
+
+//GetSecret 获取密钥引用的值,ref为提供程序:名称,未指定提供程序时使用默认提供程序
+func GetSecret(ref string) (string, error) {
+	provider, key := parseSecretRef(ref)
+	p, ok := getSecretProvider(provider)
+	if !ok {
+		return "", fmt.Errorf("密钥提供程序%s未注册", provider)
+	}
+	v, err := p.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("获取密钥%s失败:%v", ref, err)
+	}
+	return v, nil
+}
+
+//ResolveSecrets 替换内容中的 ${secret:名称} 引用,返回替换后的内容与引用的签名
+func ResolveSecrets(data []byte) ([]byte, map[string]string, error) {
+	if !secretPattern.Match(data) {
+		return data, nil, nil
+	}
+	refs := make(map[string]string)
+	var err error
+	r := secretPattern.ReplaceAllFunc(data, func(m []byte) []byte {
+		if err != nil {
+			return m
+		}
+		ref := string(secretPattern.FindSubmatch(m)[1])
+		v, e := GetSecret(ref)
+		if e != nil {
+			err = e
+			return m
+		}
+		refs[ref] = md5.Encrypt(v)
+		//引用位于json字符串中,需转义
+		buff, _ := json.Marshal(v)
+		return buff[1 : len(buff)-1]
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, refs, nil
+}
+
+//IsSecretChanged 检查引用的密钥值是否已变化,获取失败时视为未变化
+func IsSecretChanged(refs map[string]string) bool {
+	for ref, sign := range refs {
+		v, err := GetSecret(ref)
+		if err != nil {
+			continue
+		}
+		if md5.Encrypt(v) != sign {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSecretRef(ref string) (provider string, key string) {
+	if kv := strings.SplitN(ref, ":", 2); len(kv) == 2 {
+		if _, ok := getSecretProvider(kv[0]); ok {
+			return kv[0], kv[1]
+		}
+	}
+	provider = os.Getenv(SecretProviderEnv)
+	if provider == "" {
+		provider = FileSecretName
+	}
+	return provider, ref
+}
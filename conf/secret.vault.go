@@ -0,0 +1,49 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+//VaultSecretName vault密钥提供程序名称
+const VaultSecretName = "vault"
+
+//IVaultClient vault类密钥存储客户端,Read返回指定路径下的所有字段
+type IVaultClient interface {
+	Read(path string) (map[string]interface{}, error)
+}
+
+//VaultSecret vault类密钥存储提供程序,密钥名称格式为 路径#字段,未指定字段时读取value字段
+type VaultSecret struct {
+	client IVaultClient
+}
+
+//NewVaultSecret 构建vault密钥提供程序,需通过RegisterSecretProvider注册后生效
+func NewVaultSecret(client IVaultClient) *VaultSecret {
+	return &VaultSecret{client: client}
+}
+
+//Name 提供程序名称
+func (v *VaultSecret) Name() string {
+	return VaultSecretName
+}
+
+//Get 读取路径下的字段值
+func (v *VaultSecret) Get(key string) (string, error) {
+	if v.client == nil {
+		return "", fmt.Errorf("未配置vault客户端,请通过RegisterSecretProvider(NewVaultSecret(client))注册")
+	}
+	path, field := key, "value"
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		path, field = key[:i], key[i+1:]
+	}
+	data, err := v.client.Read(path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("%s未包含字段%s", path, field)
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	os.Setenv(GetSecretEnvName("db/prod/password"), `12"3`)
+	defer os.Unsetenv(GetSecretEnvName("db/prod/password"))
+	ut.Expect(t, GetSecretEnvName("/db/prod.password"), "parrot_secret_db_prod_password")
+
+	data, refs, err := ResolveSecrets([]byte(`{"connString":"order/${secret:env:db/prod/password}@orcl136"}`))
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(data), `{"connString":"order/12\"3@orcl136"}`)
+	ut.Expect(t, len(refs), 1)
+
+	_, _, err = ResolveSecrets([]byte(`{"connString":"${secret:vault:db/prod#password}"}`))
+	ut.Refute(t, err, nil)
+
+	data, refs, err = ResolveSecrets([]byte(`{"connString":"order"}`))
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(data), `{"connString":"order"}`)
+	ut.Expect(t, len(refs), 0)
+}
+
+func TestSecretRotate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "parrot-secret")
+	defer os.RemoveAll(dir)
+	RegisterSecretProvider(NewFileSecret(dir))
+	defer RegisterSecretProvider(NewFileSecret(""))
+	os.MkdirAll(filepath.Join(dir, "db", "prod"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "db", "prod", "password"), []byte("123456\n"), 0600)
+
+	rgst := mem.New("TestSecretRotate")
+	defer rgst.Close()
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+	rgst.CreatePersistentNode("/test/var/db/db", `{"provider":"ora","connString":"order/${secret:db/prod/password}@orcl136"}`)
+	data, version, _ := rgst.GetValue("/test/order/api/t/conf")
+	c, err := NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Expect(t, err, nil)
+	db, err := c.GetVarConf("db", "db")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, db.GetString("connString"), "order/123456@orcl136")
+	ut.Expect(t, c.IsSecretChanged(), false)
+
+	//输出配置时使用未替换的密钥引用
+	ut.Expect(t, string(c.GetUnresolvedRaw("/test/var/db/db")), `{"provider":"ora","connString":"order/${secret:db/prod/password}@orcl136"}`)
+	ut.Expect(t, c.GetUnresolvedRaw("/test/order/api/t/conf") == nil, true)
+
+	ioutil.WriteFile(filepath.Join(dir, "db", "prod", "password"), []byte("654321"), 0600)
+	ut.Expect(t, c.IsSecretChanged(), true)
+
+	os.Remove(filepath.Join(dir, "db", "prod", "password"))
+	ut.Expect(t, c.IsSecretChanged(), false)
+	_, err = NewServerConf("/test/order/api/t/conf", data, version, rgst)
+	ut.Refute(t, err, nil)
+}
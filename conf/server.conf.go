@@ -3,6 +3,7 @@ package conf
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -30,6 +31,7 @@ type IMainConf interface {
 	GetServerPubRootPath() string
 	IsStop() bool
 	ForceRestart() bool
+	IsSecretChanged() bool
 	GetSubObject(name string, v interface{}) (int32, error)
 	GetSubConf(name string) (*JSONConf, error)
 	HasSubConf(name ...string) bool
@@ -68,6 +70,10 @@ type ServerConf struct {
 	subNodeConfs map[string]JSONConf
 	varNodeConfs map[string]JSONConf
 	registry     registry.IRegistry
+	secrets      map[string]string
+	overlay      *Overlay
+	overlays     map[string]map[string]string
+	unresolved   map[string][]byte
 	varLock      sync.RWMutex
 	subLock      sync.RWMutex
 }
@@ -91,8 +97,14 @@ func NewServerConf(mainConfpath string, mainConfRaw []byte, mainConfVersion int3
 		registry:     rgst,
 		subNodeConfs: make(map[string]JSONConf),
 		varNodeConfs: make(map[string]JSONConf),
+		secrets:      make(map[string]string),
+		overlays:     make(map[string]map[string]string),
+		unresolved:   make(map[string][]byte),
 	}
-	rdata, err := decrypt(mainConfRaw)
+	if s.overlay, err = LoadOverlay(); err != nil {
+		return nil, err
+	}
+	rdata, err := s.resolve(mainConfpath, []string{"conf", registry.Join(sections[2], "conf")}, mainConfRaw)
 	if err != nil {
 		return nil, err
 	}
@@ -126,7 +138,7 @@ func (c *ServerConf) loadChildNodeConf() error {
 		if err != nil {
 			return err
 		}
-		rdata, err := decrypt(data)
+		rdata, err := c.resolve(childConfPath, []string{p}, data)
 		if err != nil {
 			return err
 		}
@@ -170,7 +182,7 @@ func (c *ServerConf) loadVarNodeConf() (err error) {
 			if err != nil {
 				return err
 			}
-			rdata, err := decrypt(data)
+			rdata, err := c.resolve(nodePath, []string{registry.Join("var", p, node)}, data)
 			if err != nil {
 				return err
 			}
@@ -185,6 +197,82 @@ func (c *ServerConf) loadVarNodeConf() (err error) {
 	return nil
 }
 
+//resolve 解密配置,转换为json,合并本地覆盖配置并替换密钥引用,names为覆盖配置中的节点名称
+func (c *ServerConf) resolve(path string, names []string, data []byte) ([]byte, error) {
+	rdata, err := decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	//yaml,toml转换为json后再替换密钥引用,确保替换值按json转义
+	if rdata, err = ToJSON(rdata); err != nil {
+		return nil, fmt.Errorf("%s配置有误:%v", path, err)
+	}
+	rdata, sources, err := c.overlay.Apply(names, rdata)
+	if err != nil {
+		return nil, fmt.Errorf("%s合并覆盖配置失败:%v", path, err)
+	}
+	if len(sources) > 0 {
+		c.overlays[path] = sources
+	}
+	raw := rdata
+	rdata, refs, err := ResolveSecrets(rdata)
+	if err != nil {
+		return nil, fmt.Errorf("%s配置有误:%v", path, err)
+	}
+	if len(refs) > 0 {
+		c.unresolved[path] = raw
+	}
+	for k, v := range refs {
+		c.secrets[k] = v
+	}
+	return rdata, nil
+}
+
+//GetUnresolvedRaw 获取节点替换密钥引用前的配置,用于输出配置时不显示密钥;节点未引用密钥时返回nil
+func (c *ServerConf) GetUnresolvedRaw(path string) []byte {
+	return c.unresolved[path]
+}
+
+//GetOverlaySources 获取节点中被本地覆盖配置修改的键及来源
+func (c *ServerConf) GetOverlaySources(path string) map[string]string {
+	return c.overlays[path]
+}
+
+//IsSecretChanged 配置引用的密钥是否已更新
+func (c *ServerConf) IsSecretChanged() bool {
+	return IsSecretChanged(c.secrets)
+}
+
+//Validate 根据注册的校验规则检查子节点与var节点配置
+func (c *ServerConf) Validate() error {
+	c.subLock.RLock()
+	defer c.subLock.RUnlock()
+	for _, k := range sortedKeys(c.subNodeConfs) {
+		conf := c.subNodeConfs[k]
+		if err := ValidateConf(k, &conf); err != nil {
+			return fmt.Errorf("%s配置有误:%v", registry.Join(c.mainConfpath, k), err)
+		}
+	}
+	c.varLock.RLock()
+	defer c.varLock.RUnlock()
+	for _, k := range sortedKeys(c.varNodeConfs) {
+		conf := c.varNodeConfs[k]
+		if err := ValidateConf(registry.Join("var", k), &conf); err != nil {
+			return fmt.Errorf("%s配置有误:%v", registry.Join(c.varConfPath, k), err)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]JSONConf) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 //IsStop 当前服务是否已停止
 func (c *ServerConf) IsStop() bool {
 	return c.GetString("status", "start") != "start" && c.GetString("status", "start") != "restart"
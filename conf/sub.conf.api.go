@@ -11,6 +11,7 @@ type APIServerConf struct {
 	RTimeout  int    `json:"readTimeout,omitempty"`
 	WTimeout  int    `json:"writeTimeout,omitempty"`
 	RHTimeout int    `json:"readHeaderTimeout,omitempty"`
+	Drain     int    `json:"drainTimeout,omitempty"`
 	Hosts     string `json:"host,omitempty"`
 	Trace     bool   `json:"trace,omitempty"`
 }
@@ -47,6 +48,12 @@ func (a *APIServerConf) WithHost(host ...string) *APIServerConf {
 	return a
 }
 
+//WithDrainTimeout 设置关闭或重启时等待处理中的请求完成的最长时间(秒)
+func (a *APIServerConf) WithDrainTimeout(timeout int) *APIServerConf {
+	a.Drain = timeout
+	return a
+}
+
 //WithDisable 禁用任务
 func (a *APIServerConf) WithDisable() *APIServerConf {
 	a.Status = "stop"
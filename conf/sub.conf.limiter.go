@@ -0,0 +1,147 @@
+package conf
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+)
+
+//Limiter 限流配置,cache为var/cache下的redis配置名称,设置后多个实例共享令牌桶,未设置时在本地限流;
+//trustedProxies为受信任的代理地址(ip或cidr),仅来自受信任代理的请求使用X-Forwarded-For获取客户端ip
+type Limiter struct {
+	Cache          string       `json:"cache,omitempty" valid:"ascii"`
+	Rules          []*LimitRule `json:"rules" valid:"required"`
+	TrustedProxies []string     `json:"trustedProxies,omitempty"`
+	Disable        bool         `json:"disable,omitempty"`
+}
+
+//LimitRule 路由限流规则
+//url支持完全匹配,*(所有请求),/a/*(单段模糊)与/a/**(多段模糊),按配置顺序取第一个匹配的规则;
+//by为限流依据:ip(默认),jwt(name为jwt中的字段),header(name为请求头),appkey(name为参数名,默认为appkey);
+//rate为每秒生成的令牌数,burst为令牌桶容量,默认与rate相同
+type LimitRule struct {
+	URL     string  `json:"url" valid:"ascii,required"`
+	By      string  `json:"by,omitempty" valid:"in(ip|jwt|header|appkey)"`
+	Name    string  `json:"name,omitempty" valid:"ascii"`
+	Rate    float64 `json:"rate"`
+	Burst   int     `json:"burst,omitempty"`
+	Disable bool    `json:"disable,omitempty"`
+}
+
+//NewLimiter 构建限流配置
+func NewLimiter(rules ...*LimitRule) *Limiter {
+	return &Limiter{Rules: rules}
+}
+
+//WithCache 使用var/cache中的redis配置在多个实例间共享限流
+func (l *Limiter) WithCache(name string) *Limiter {
+	l.Cache = name
+	return l
+}
+
+//WithTrustedProxies 设置受信任的代理地址(ip或cidr)
+func (l *Limiter) WithTrustedProxies(proxies ...string) *Limiter {
+	l.TrustedProxies = append(l.TrustedProxies, proxies...)
+	return l
+}
+
+//IsTrustedProxy 检查ip是否为受信任的代理
+func (l *Limiter) IsTrustedProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, p := range l.TrustedProxies {
+		if _, n, err := net.ParseCIDR(p); err == nil {
+			if n.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if v := net.ParseIP(p); v != nil && v.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+//NewLimitRule 构建限流规则
+func NewLimitRule(url string, by string, rate float64, burst int) *LimitRule {
+	return &LimitRule{URL: url, By: by, Rate: rate, Burst: burst}
+}
+
+//WithName 设置jwt字段,请求头或参数名称
+func (r *LimitRule) WithName(name string) *LimitRule {
+	r.Name = name
+	return r
+}
+
+//GetBurst 获取令牌桶容量
+func (r *LimitRule) GetBurst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	if r.Rate < 1 {
+		return 1
+	}
+	return int(r.Rate)
+}
+
+//Match 检查url是否匹配规则
+func (r *LimitRule) Match(url string) bool {
+	if r.URL == "*" || strings.EqualFold(r.URL, url) {
+		return true
+	}
+	patterns := strings.Split(strings.Trim(r.URL, "/"), "/")
+	sections := strings.Split(strings.Trim(url, "/"), "/")
+	for i, p := range patterns {
+		if p == "**" {
+			return true
+		}
+		if i >= len(sections) || (p != "*" && !strings.EqualFold(p, sections[i])) {
+			return false
+		}
+	}
+	return len(patterns) == len(sections)
+}
+
+//GetRule 获取url对应的限流规则,未配置时返回nil
+func (l *Limiter) GetRule(url string) *LimitRule {
+	for _, r := range l.Rules {
+		if !r.Disable && r.Match(url) {
+			return r
+		}
+	}
+	return nil
+}
+
+//Validate 校验配置
+func (l *Limiter) Validate() error {
+	if b, err := govalidator.ValidateStruct(l); !b {
+		return err
+	}
+	for _, p := range l.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return fmt.Errorf("trustedProxies:%s不是有效的ip或cidr", p)
+		}
+	}
+	for i, r := range l.Rules {
+		if r.Rate <= 0 {
+			return fmt.Errorf("第%d项:rate必须大于0", i+1)
+		}
+		if (r.By == "jwt" || r.By == "header") && r.Name == "" {
+			return fmt.Errorf("第%d项:by为%s时name不能为空", i+1, r.By)
+		}
+	}
+	return nil
+}
+
+func validateLimiter(c *JSONConf) error {
+	var l Limiter
+	if err := c.Unmarshal(&l); err != nil {
+		return err
+	}
+	return l.Validate()
+}
@@ -1,11 +1,31 @@
 package conf
 
+import (
+	"fmt"
+
+	"github.com/asaskevich/govalidator"
+)
+
+const (
+	//MetricModeInfluxDB 定时推送到influxdb,未指定mode时的默认值
+	MetricModeInfluxDB = "influxdb"
+	//MetricModePrometheus 提供prometheus格式的/metrics接口
+	MetricModePrometheus = "prometheus"
+	//MetricModeAll 同时推送influxdb并提供/metrics接口
+	MetricModeAll = "all"
+)
+
+//Metric metric配置,mode为influxdb时host,dataBase,cron为必须项;
+//mode为prometheus时address为空则在当前http服务器上提供path接口,否则在address上单独监听
 type Metric struct {
-	Host     string `json:"host" valid:"requrl,required"`
-	DataBase string `json:"dataBase" valid:"ascii,required"`
-	Cron     string `json:"cron" valid:"ascii,required"`
+	Host     string `json:"host,omitempty" valid:"requrl"`
+	DataBase string `json:"dataBase,omitempty" valid:"ascii"`
+	Cron     string `json:"cron,omitempty" valid:"ascii"`
 	UserName string `json:"userName,omitempty" valid:"ascii"`
 	Password string `json:"password,omitempty" valid:"ascii"`
+	Mode     string `json:"mode,omitempty" valid:"in(influxdb|prometheus|all)"`
+	Path     string `json:"path,omitempty" valid:"ascii"`
+	Address  string `json:"address,omitempty" valid:"ascii"`
 	Disable  bool   `json:"disable,omitempty"`
 }
 
@@ -18,6 +38,44 @@ func NewMetric(host string, db string, cron string) *Metric {
 	}
 }
 
+//NewPrometheusMetric 构建prometheus metric配置,address为空时在当前http服务器上提供/metrics接口
+func NewPrometheusMetric(address ...string) *Metric {
+	m := &Metric{Mode: MetricModePrometheus}
+	if len(address) > 0 {
+		m.Address = address[0]
+	}
+	return m
+}
+
+//EnableInfluxDB 是否推送到influxdb
+func (m *Metric) EnableInfluxDB() bool {
+	return m.Mode == "" || m.Mode == MetricModeInfluxDB || m.Mode == MetricModeAll
+}
+
+//EnablePrometheus 是否提供prometheus接口
+func (m *Metric) EnablePrometheus() bool {
+	return m.Mode == MetricModePrometheus || m.Mode == MetricModeAll
+}
+
+//GetPath 获取prometheus接口路径
+func (m *Metric) GetPath() string {
+	if m.Path == "" {
+		return "/metrics"
+	}
+	return m.Path
+}
+
+//Validate 校验metric配置
+func (m *Metric) Validate() error {
+	if b, err := govalidator.ValidateStruct(m); !b {
+		return err
+	}
+	if m.EnableInfluxDB() && (m.Host == "" || m.DataBase == "" || m.Cron == "") {
+		return fmt.Errorf("host,dataBase,cron不能为空")
+	}
+	return nil
+}
+
 //WithUserName 设置用户名密码
 func (m *Metric) WithUserName(uname string, p string) *Metric {
 	m.UserName = uname
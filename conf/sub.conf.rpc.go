@@ -7,6 +7,7 @@ type RPCServerConf struct {
 	RTimeout  int    `json:"readTimeout,omitempty"`
 	WTimeout  int    `json:"writeTimeout,omitempty"`
 	RHTimeout int    `json:"readHeaderTimeout,omitempty"`
+	Drain     int    `json:"drainTimeout,omitempty"`
 	Trace     bool   `json:"trace,omitempty"`
 }
 
@@ -23,6 +24,12 @@ func (a *RPCServerConf) WithTrace() *RPCServerConf {
 	return a
 }
 
+//WithDrainTimeout 设置关闭或重启时等待处理中的请求完成的最长时间(秒)
+func (a *RPCServerConf) WithDrainTimeout(timeout int) *RPCServerConf {
+	a.Drain = timeout
+	return a
+}
+
 //WithDisable 禁用任务
 func (a *RPCServerConf) WithDisable() *RPCServerConf {
 	a.Status = "stop"
@@ -0,0 +1,83 @@
+package conf
+
+import (
+	"fmt"
+
+	"github.com/asaskevich/govalidator"
+)
+
+const (
+	//TracingExporterOTLP 通过OTLP/HTTP(json)推送到collector
+	TracingExporterOTLP = "otlp"
+	//TracingExporterFile 以json行写入本地文件,用于离线调试
+	TracingExporterFile = "file"
+)
+
+//Tracing 链路跟踪配置,exporter为otlp时endpoint为collector地址,为file时path为输出文件
+type Tracing struct {
+	Exporter string            `json:"exporter" valid:"in(otlp|file),required"`
+	Endpoint string            `json:"endpoint,omitempty" valid:"requrl"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Path     string            `json:"path,omitempty"`
+	Ratio    float64           `json:"ratio,omitempty"`
+	Disable  bool              `json:"disable,omitempty"`
+}
+
+//NewOTLPTracing 构建OTLP/HTTP链路跟踪配置
+func NewOTLPTracing(endpoint string) *Tracing {
+	return &Tracing{Exporter: TracingExporterOTLP, Endpoint: endpoint}
+}
+
+//NewFileTracing 构建本地文件链路跟踪配置
+func NewFileTracing(path string) *Tracing {
+	return &Tracing{Exporter: TracingExporterFile, Path: path}
+}
+
+//WithRatio 设置采样比例,未设置时全部采样
+func (t *Tracing) WithRatio(ratio float64) *Tracing {
+	t.Ratio = ratio
+	return t
+}
+
+//GetEndpoint 获取collector地址,默认为本机4318端口
+func (t *Tracing) GetEndpoint() string {
+	if t.Endpoint == "" {
+		return "http://localhost:4318/v1/traces"
+	}
+	return t.Endpoint
+}
+
+//GetPath 获取输出文件,默认为../logs/trace.json
+func (t *Tracing) GetPath() string {
+	if t.Path == "" {
+		return "../logs/trace.json"
+	}
+	return t.Path
+}
+
+//GetRatio 获取采样比例
+func (t *Tracing) GetRatio() float64 {
+	if t.Ratio <= 0 {
+		return 1
+	}
+	return t.Ratio
+}
+
+//Validate 校验配置
+func (t *Tracing) Validate() error {
+	if b, err := govalidator.ValidateStruct(t); !b {
+		return err
+	}
+	if t.Ratio < 0 || t.Ratio > 1 {
+		return fmt.Errorf("ratio必须在0-1之间")
+	}
+	return nil
+}
+
+func validateTracing(c *JSONConf) error {
+	var t Tracing
+	if err := c.Unmarshal(&t); err != nil {
+		return err
+	}
+	return t.Validate()
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/sereiner/library/security/md5"
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/registry"
+	"github.com/sereiner/parrot/trace"
 )
 
 type IContainer interface {
@@ -63,6 +64,7 @@ type Context struct {
 	Engine    string
 	Service   string
 	GinContext *gin.Context
+	Span      *trace.Span
 }
 
 //GetContext 从缓存池中获取一个context
@@ -77,11 +79,15 @@ func GetContext(g *gin.Context,component interface{}, name string, engine string
 	c.Service = formatName(c.Request.Translate(service, false))
 	c.Meta = NewMeta()
 	c.GinContext = g
+	c.Span, _ = ext["__trace_span_"].(*trace.Span)
 	return c
 }
 
-//GetContainer 获取当前容器
+//GetContainer 获取当前容器,启用链路跟踪时db,cache,queue操作记录为当前请求的子span
 func (c *Context) GetContainer() IContainer {
+	if c.Span != nil && c.container != nil {
+		return &tracedContainer{IContainer: c.container, span: c.Span}
+	}
 	return c.container
 }
 
@@ -120,6 +126,7 @@ func (c *Context) Close() {
 	c.RPC.clear()
 	c.container = nil
 	c.GinContext = nil
+	c.Span = nil
 	contextPool.Put(c)
 }
 func formatName(name string) string {
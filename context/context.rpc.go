@@ -6,6 +6,7 @@ import (
 
 	"github.com/sereiner/library/jsons"
 	"github.com/sereiner/library/rpc"
+	"github.com/sereiner/parrot/trace"
 )
 
 type RPCInvoker interface {
@@ -41,6 +42,15 @@ func (cr *ContextRPC) reset(ctx *Context, rpc RPCInvoker) {
 	cr.rpc = rpc
 }
 
+//startSpan 创建rpc请求的子span,通过traceparent请求头传递给下游服务
+func (cr *ContextRPC) startSpan(service string, header map[string]string) *trace.Span {
+	s := cr.ctx.Span.Child("rpc "+service, trace.KindClient).SetAttribute("rpc.service", service)
+	if s != nil {
+		header[trace.HeaderName] = s.TraceParent()
+	}
+	return s
+}
+
 //PreInit 预加载服务
 func (cr *ContextRPC) PreInit(services ...string) error {
 	return cr.rpc.PreInit()
@@ -61,6 +71,8 @@ func (cr *ContextRPC) AsyncRequest(service string, header map[string]string, for
 	if !ok {
 		method = "get"
 	}
+	s := cr.startSpan(service, header).SetAttribute("rpc.async", true)
+	defer s.End()
 	return cr.rpc.AsyncRequest(service, method, header, form, failFast)
 }
 
@@ -76,7 +88,10 @@ func (cr *ContextRPC) RequestFailRetry(service string, header map[string]string,
 	if !ok {
 		method = "get"
 	}
+	s := cr.startSpan(service, header)
+	defer s.End()
 	status, r, param, err = cr.rpc.RequestFailRetry(service, method, header, form, times)
+	s.SetAttribute("rpc.status", status).SetError(err)
 	if err != nil || status != 200 {
 		return
 	}
@@ -101,7 +116,10 @@ func (cr *ContextRPC) Request(service string, header map[string]string, form map
 	if !ok {
 		method = "get"
 	}
+	s := cr.startSpan(service, header)
+	defer s.End()
 	status, r, param, err = cr.rpc.Request(service, method, header, form, failFast)
+	s.SetAttribute("rpc.status", status).SetError(err)
 	if err != nil || status != 200 {
 		return
 	}
@@ -0,0 +1,211 @@
+package context
+
+import (
+	"github.com/sereiner/library/cache"
+	"github.com/sereiner/library/db"
+	"github.com/sereiner/library/queue"
+	"github.com/sereiner/parrot/trace"
+)
+
+//tracedContainer 将db,cache,queue操作记录为请求span的子span
+type tracedContainer struct {
+	IContainer
+	span *trace.Span
+}
+
+func getName(names []string, def string) string {
+	if len(names) > 0 && names[0] != "" {
+		return names[0]
+	}
+	return def
+}
+
+func (c *tracedContainer) GetRegularDB(names ...string) db.IDB {
+	return &tracedDB{IDB: c.IContainer.GetRegularDB(names...), span: c.span, name: getName(names, "db")}
+}
+func (c *tracedContainer) GetDB(names ...string) (db.IDB, error) {
+	d, err := c.IContainer.GetDB(names...)
+	if err != nil {
+		return d, err
+	}
+	return &tracedDB{IDB: d, span: c.span, name: getName(names, "db")}, nil
+}
+func (c *tracedContainer) GetDBBy(tpName string, name string) (db.IDB, error) {
+	d, err := c.IContainer.GetDBBy(tpName, name)
+	if err != nil {
+		return d, err
+	}
+	return &tracedDB{IDB: d, span: c.span, name: name}, nil
+}
+func (c *tracedContainer) GetRegularCache(names ...string) cache.ICache {
+	return &tracedCache{ICache: c.IContainer.GetRegularCache(names...), span: c.span, name: getName(names, "cache")}
+}
+func (c *tracedContainer) GetCache(names ...string) (cache.ICache, error) {
+	r, err := c.IContainer.GetCache(names...)
+	if err != nil {
+		return r, err
+	}
+	return &tracedCache{ICache: r, span: c.span, name: getName(names, "cache")}, nil
+}
+func (c *tracedContainer) GetCacheBy(tpName string, name string) (cache.ICache, error) {
+	r, err := c.IContainer.GetCacheBy(tpName, name)
+	if err != nil {
+		return r, err
+	}
+	return &tracedCache{ICache: r, span: c.span, name: name}, nil
+}
+func (c *tracedContainer) GetRegularQueue(names ...string) queue.IQueue {
+	return &tracedQueue{IQueue: c.IContainer.GetRegularQueue(names...), span: c.span, name: getName(names, "queue")}
+}
+func (c *tracedContainer) GetQueue(names ...string) (queue.IQueue, error) {
+	q, err := c.IContainer.GetQueue(names...)
+	if err != nil {
+		return q, err
+	}
+	return &tracedQueue{IQueue: q, span: c.span, name: getName(names, "queue")}, nil
+}
+func (c *tracedContainer) GetQueueBy(tpName string, name string) (queue.IQueue, error) {
+	q, err := c.IContainer.GetQueueBy(tpName, name)
+	if err != nil {
+		return q, err
+	}
+	return &tracedQueue{IQueue: q, span: c.span, name: name}, nil
+}
+
+type tracedDB struct {
+	db.IDB
+	span *trace.Span
+	name string
+}
+
+func (d *tracedDB) start(op string, sql string) *trace.Span {
+	return d.span.Child("db."+op, trace.KindClient).SetAttribute("db.name", d.name).SetAttribute("db.statement", sql)
+}
+func (d *tracedDB) Query(sql string, input map[string]interface{}) (data db.QueryRows, query string, args []interface{}, err error) {
+	s := d.start("query", sql)
+	defer s.End()
+	data, query, args, err = d.IDB.Query(sql, input)
+	s.SetError(err)
+	return
+}
+func (d *tracedDB) Scalar(sql string, input map[string]interface{}) (data interface{}, query string, args []interface{}, err error) {
+	s := d.start("scalar", sql)
+	defer s.End()
+	data, query, args, err = d.IDB.Scalar(sql, input)
+	s.SetError(err)
+	return
+}
+func (d *tracedDB) Execute(sql string, input map[string]interface{}) (row int64, query string, args []interface{}, err error) {
+	s := d.start("execute", sql)
+	defer s.End()
+	row, query, args, err = d.IDB.Execute(sql, input)
+	s.SetError(err)
+	return
+}
+func (d *tracedDB) Executes(sql string, input map[string]interface{}) (lastInsertID, affectedRow int64, query string, args []interface{}, err error) {
+	s := d.start("execute", sql)
+	defer s.End()
+	lastInsertID, affectedRow, query, args, err = d.IDB.Executes(sql, input)
+	s.SetError(err)
+	return
+}
+func (d *tracedDB) ExecuteSP(procName string, input map[string]interface{}, output ...interface{}) (row int64, query string, err error) {
+	s := d.start("execute_sp", procName)
+	defer s.End()
+	row, query, err = d.IDB.ExecuteSP(procName, input, output...)
+	s.SetError(err)
+	return
+}
+
+type tracedCache struct {
+	cache.ICache
+	span *trace.Span
+	name string
+}
+
+func (c *tracedCache) start(op string, key string) *trace.Span {
+	return c.span.Child("cache."+op, trace.KindClient).SetAttribute("cache.name", c.name).SetAttribute("cache.key", key)
+}
+func (c *tracedCache) Get(key string) (r string, err error) {
+	s := c.start("get", key)
+	defer s.End()
+	r, err = c.ICache.Get(key)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Gets(key ...string) (r []string, err error) {
+	s := c.start("gets", getName(key, ""))
+	defer s.End()
+	r, err = c.ICache.Gets(key...)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Decrement(key string, delta int64) (n int64, err error) {
+	s := c.start("decrement", key)
+	defer s.End()
+	n, err = c.ICache.Decrement(key, delta)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Increment(key string, delta int64) (n int64, err error) {
+	s := c.start("increment", key)
+	defer s.End()
+	n, err = c.ICache.Increment(key, delta)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Add(key string, value string, expiresAt int) (err error) {
+	s := c.start("add", key)
+	defer s.End()
+	err = c.ICache.Add(key, value, expiresAt)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Set(key string, value string, expiresAt int) (err error) {
+	s := c.start("set", key)
+	defer s.End()
+	err = c.ICache.Set(key, value, expiresAt)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Delete(key string) (err error) {
+	s := c.start("delete", key)
+	defer s.End()
+	err = c.ICache.Delete(key)
+	s.SetError(err)
+	return
+}
+func (c *tracedCache) Exists(key string) bool {
+	s := c.start("exists", key)
+	defer s.End()
+	return c.ICache.Exists(key)
+}
+func (c *tracedCache) Delay(key string, expiresAt int) (err error) {
+	s := c.start("delay", key)
+	defer s.End()
+	err = c.ICache.Delay(key, expiresAt)
+	s.SetError(err)
+	return
+}
+
+type tracedQueue struct {
+	queue.IQueue
+	span *trace.Span
+	name string
+}
+
+//Push 发送消息,json对象格式的消息附加traceparent,由mqc服务器在消费时还原
+func (q *tracedQueue) Push(key string, value string) (err error) {
+	s := q.span.Child("queue.push", trace.KindProducer).SetAttribute("queue.name", q.name).SetAttribute("messaging.destination", key)
+	defer s.End()
+	err = q.IQueue.Push(key, trace.InjectMessage(value, s.TraceParent()))
+	s.SetError(err)
+	return
+}
+func (q *tracedQueue) Pop(key string) (r string, err error) {
+	s := q.span.Child("queue.pop", trace.KindClient).SetAttribute("queue.name", q.name).SetAttribute("messaging.destination", key)
+	defer s.End()
+	r, err = q.IQueue.Pop(key)
+	s.SetError(err)
+	return
+}
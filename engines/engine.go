@@ -1,6 +1,7 @@
 package engines
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"strconv"
@@ -114,10 +115,19 @@ func (r *ServiceEngine) SetHandler(h component.IComponentHandler) error {
 	return err
 }
 
-//UpdateVarConf 更新var配置参数
+//UpdateVarConf 更新var配置参数,并移除配置已变化的db,cache,queue等对象,下次使用时按新配置创建
 func (r *ServiceEngine) UpdateVarConf(conf conf.IServerConf) {
 	r.SetVarConf(conf.GetVarConfClone())
 	r.SetSubConf(conf.GetSubConfClone())
+	names := make([]string, 0, 1)
+	names = append(names, r.IComponentDB.ReleaseDBObjects()...)
+	names = append(names, r.IComponentCache.ReleaseCacheObjects()...)
+	names = append(names, r.IComponentQueue.ReleaseQueueObjects()...)
+	names = append(names, r.IComponentInfluxDB.ReleaseInfluxObjects()...)
+	names = append(names, r.IComponentGlobalVarObject.ReleaseGlobalObjects()...)
+	for _, name := range names {
+		r.logger.Infof("var配置已变化,重新创建:%s", name)
+	}
 }
 
 //GetServices 获取组件提供的所有服务
@@ -193,6 +203,23 @@ func (r *ServiceEngine) GetRegistry() registry.IRegistry {
 	return r.registry
 }
 
+//CheckHealth 执行注册的就绪检查,返回所有失败项
+func (r *ServiceEngine) CheckHealth() error {
+	if r.cHandler == nil {
+		return nil
+	}
+	errs := make([]string, 0, 1)
+	for _, h := range r.cHandler.GetHealthChecks() {
+		if err := h.Check(r); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%v", h.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ";"))
+	}
+	return nil
+}
+
 //Close 关闭引擎
 func (r *ServiceEngine) Close() error {
 	if r.cHandler != nil {
@@ -4,7 +4,9 @@ go 1.13
 
 require (
 	code.cloudfoundry.org/bytefmt v0.0.0-20180906201452-2aa6f33b730c // indirect
+	github.com/BurntSushi/toml v0.3.1
 	github.com/asaskevich/govalidator v0.0.0-20190424111038-f61b66f89f4a
+	github.com/coreos/etcd v3.3.15+incompatible
 	github.com/dsnet/compress v0.0.1 // indirect
 	github.com/fsnotify/fsnotify v1.4.7
 	github.com/gin-contrib/sse v0.1.0 // indirect
@@ -23,6 +25,7 @@ require (
 	github.com/pierrec/cmdflag v0.0.2 // indirect
 	github.com/pkg/profile v1.3.0
 	github.com/prometheus/client_model v0.0.0-20190812154241-14fe0d1b01d4 // indirect
+	github.com/samuel/go-zookeeper v0.0.0-20180130194729-c4fab1ac1bec
 	github.com/schollz/progressbar/v2 v2.12.1 // indirect
 	github.com/sereiner/library v0.0.0-20191208162151-380f475a5e31
 	github.com/shirou/gopsutil v2.19.6+incompatible // indirect
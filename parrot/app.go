@@ -105,7 +105,7 @@ func (m *MicroApp) action(c *cli.Context) (err error) {
 		defer m.remoteQueryService.Shutdown()
 	}
 
-	m.parrot = Newparrot(m.app.Name, m.PlatName, m.SystemName, m.ServerTypes, m.ClusterName, m.Trace,
+	m.parrot = Newparrot(m.app.Name, m.PlatName, m.SystemName, m.ServerTypes, m.ClusterName, m.Trace, m.HealthAddr,
 		m.RegistryAddr, m.IsDebug, m.RemoteLogger, m.logger, m.IComponentRegistry, m.PbFunc)
 
 	m.run()
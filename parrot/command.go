@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/sereiner/parrot/conf"
 	"github.com/urfave/cli"
 )
 
@@ -72,10 +73,86 @@ func (m *MicroApp) getCommands() []cli.Command {
 			Usage:  "查询服务状态",
 			Action: m.statusAction,
 		}, {
-			Name:   "conf",
-			Usage:  "查看配置信息。查看当前服务在配置中心的配置信息",
-			Flags:  m.getStartFlags("conf"),
+			Name:  "conf",
+			Usage: "查看配置信息。查看当前服务在配置中心的配置信息",
+			Flags: append(m.getStartFlags("conf"), cli.StringFlag{
+				Name:  "format,f",
+				Value: conf.FormatJSON,
+				Usage: "-输出格式,可选项。支持json,yaml,toml",
+			}),
 			Action: m.queryConfigAction,
+		}, {
+			Name:  "export",
+			Usage: "导出配置信息。将服务器配置,平台变量配置与安装包配置导出到目录或压缩包(.tar.gz)",
+			Flags: append(m.getStartFlags("export"), cli.StringFlag{
+				Name:  "output,o",
+				Usage: "\033[;31m*\033[0m导出目录或压缩包路径,以.tar.gz或.tgz结尾时导出为压缩包",
+			}),
+			Action: m.exportAction,
+		}, {
+			Name:  "import",
+			Usage: "导入配置信息。将export导出的配置导入到当前注册中心,平台,系统,集群名称替换为当前服务的名称",
+			Flags: append(m.getStartFlags("import"), cli.StringFlag{
+				Name:  "input,i",
+				Usage: "\033[;31m*\033[0m导入目录或压缩包路径",
+			}, cli.BoolFlag{
+				Name:  "cover",
+				Usage: "-覆盖已存在的配置,可选项。默认跳过已存在的配置",
+			}),
+			Action: m.importAction,
+		}, {
+			Name:   "lint",
+			Usage:  "检查配置。校验注册中心中的服务器配置,cron表达式,路由服务与引用的var节点,有问题时返回非0退出码",
+			Flags:  m.getStartFlags("lint"),
+			Action: m.lintAction,
+		}, {
+			Name:  "history",
+			Usage: "配置历史。查看服务器已应用配置的历史版本,比较差异或回滚到指定版本",
+			Subcommands: []cli.Command{
+				{
+					Name:      "list",
+					Usage:     "查看节点的历史版本,未指定节点时列出所有有历史记录的节点",
+					ArgsUsage: "[节点路径]",
+					Flags:     m.getStartFlags("history"),
+					Action:    m.historyListAction,
+				}, {
+					Name:      "diff",
+					Usage:     "比较两个历史版本,未指定第二个版本时与当前配置比较",
+					ArgsUsage: "<节点路径> <版本> [版本]",
+					Flags:     m.getStartFlags("history"),
+					Action:    m.historyDiffAction,
+				}, {
+					Name:      "rollback",
+					Usage:     "回滚到指定版本,并通知服务器重新加载配置",
+					ArgsUsage: "<节点路径> <版本>",
+					Flags:     m.getStartFlags("history"),
+					Action:    m.historyRollbackAction,
+				},
+			},
+		}, {
+			Name:  "crypt",
+			Usage: "配置加解密。加密配置内容后保存到注册中心,服务启动时自动解密",
+			Subcommands: []cli.Command{
+				{
+					Name:      "encrypt",
+					Usage:     "加密配置内容,未指定内容时从标准输入读取",
+					ArgsUsage: "[内容]",
+					Flags:     getCryptFlags(),
+					Action:    m.encryptAction,
+				}, {
+					Name:      "decrypt",
+					Usage:     "解密配置内容,未指定内容时从标准输入读取",
+					ArgsUsage: "[密文]",
+					Flags:     getCryptFlags(),
+					Action:    m.decryptAction,
+				}, {
+					Name:      "genkey",
+					Usage:     "生成新密钥并设置为当前加密使用的密钥,已有密钥保留用于解密",
+					ArgsUsage: "<密钥编号>",
+					Flags:     getCryptFlags(),
+					Action:    m.genkeyAction,
+				},
+			},
 		}, {
 			Name:   "v",
 			Usage:  "查看版本信息,编译时间",
@@ -92,11 +169,11 @@ func (m *MicroApp) getStartFlags(name string) []cli.Flag {
 			Destination: &m.RegistryAddr,
 			EnvVar:      "parrot_registry",
 			Usage: "\033[;31m*\033[0m" + `注册中心地址,必须项。目前支持zookeeper(zk),
-	 etcd(etcd只用于grpc的服务发现和负载均衡,与zk共用一个注册地址)和本地文件系统(fs)。
-	 注册中心用于保存服务启动和运行参数，服务注册与发现等数据，格式:proto://host。proto的取值有zk,fs;
-	 host的取值根据不同的注册中心各不同,如zookeeper则为ip地址(加端口号),
-	 多个ip用逗号分隔,如:zk://192.168.0.2,192.168.0.107:12181。本地文
-	 件系统为本地文件路径，可以是相对路径或绝对路径,如:fs://../;  
+	 etcd(etcd),本地文件系统(fs)和内存(mem,用于测试)。
+	 注册中心用于保存服务启动和运行参数，服务注册与发现等数据，格式:proto://host。proto的取值有zk,etcd,fs,mem;
+	 host的取值根据不同的注册中心各不同,如zookeeper,etcd则为ip地址(加端口号),
+	 多个ip用逗号分隔,如:zk://192.168.0.2,192.168.0.107:12181,etcd://192.168.0.2:2379。本地文
+	 件系统为本地文件路径，可以是相对路径或绝对路径,如:fs://../;内存注册中心为存储名称,如:mem://test;  
      此参数可以通过命令行参数指定，程序指定，也可从环境变量中获取，环境变量名为:`,
 		})
 	}
@@ -152,6 +229,13 @@ func (m *MicroApp) getStartFlags(name string) []cli.Flag {
 		Usage: `-性能跟踪，可选项。用于生成golang的pprof的性能分析数据,支持的模式有:cpu,mem,block,mutex,web。其中web是以http
 	 服务的方式提供pprof数据。该参数可从环境变量中获取，环境变量名为:`,
 	})
+	flags = append(flags, cli.StringFlag{
+		Name:        "health,H",
+		Destination: &m.HealthAddr,
+		EnvVar:      "parrot_health",
+		Usage: `-健康检查地址,可选项。如:":10190",指定后启动http服务提供/healthz(进程存活),/livez(存活检查),/readyz(就绪检查)
+	 接口,就绪检查包括服务器状态,注册中心连接,消息队列连接与通过Health注册的检查项,退出时/readyz返回503。该参数可从环境变量中获取，环境变量名为:`,
+	})
 	flags = append(flags, cli.BoolFlag{
 		Name:        "rlog,l",
 		Destination: &m.remoteLogger,
@@ -167,6 +251,40 @@ func (m *MicroApp) getStartFlags(name string) []cli.Flag {
 	 服务状态，通过http://host/update/:version远程更新系统，执行远程更新后服务器将自动从注册中心下载安装包，自动安装并重启服务。
 	 该参数可从环境变量中获取，环境变量名为:`,
 	})
+	if name == "install" || name == "registry" {
+		flags = append(flags, m.getInstallFlags()...)
+	}
 	flags = append(flags, m.Cli.getFlags(name)...)
 	return flags
 }
+
+func (m *MicroApp) getInstallFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:        "mode,m",
+			Destination: &m.InstallMode,
+			EnvVar:      "parrot_install_mode",
+			Usage: `-安装模式,可选项。支持的模式有:skip(存在则不安装),cover(存在则覆盖),new(删除所有配置并重建)。未指定时
+	 通过命令行交互选择。该参数可从环境变量中获取，环境变量名为:`,
+		},
+		cli.StringFlag{
+			Name:        "answers,a",
+			Destination: &m.AnswersFile,
+			EnvVar:      "parrot_answers",
+			Usage: `-参数文件,可选项。json或yaml格式的参数名与参数值的键值对,用于设置配置中的#参数。参数值也可通过环境变量
+	 parrot_param_参数名设置,环境变量优先。指定参数文件后不再通过命令行交互输入。该参数可从环境变量中获取，环境变量名为:`,
+		},
+		cli.BoolFlag{
+			Name:        "non-interactive,y",
+			Destination: &m.NonInteractive,
+			EnvVar:      "parrot_non_interactive",
+			Usage: `-非交互模式,可选项。不通过命令行交互输入参数,必须指定安装模式,存在未设置的参数时安装失败。该参数可从环境变量
+	 中获取，环境变量名为:`,
+		},
+		cli.BoolFlag{
+			Name:        "dry-run",
+			Destination: &m.DryRun,
+			Usage:       `-只输出安装计划,可选项。列出将要创建,覆盖或删除的配置及与注册中心现有配置的差异,不修改注册中心,不安装本地服务`,
+		},
+	}
+}
@@ -2,8 +2,8 @@ package parrot
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sereiner/library/types"
@@ -18,6 +18,12 @@ func (m *MicroApp) queryConfigAction(c *cli.Context) (err error) {
 		cli.ShowCommandHelp(c, c.Command.Name)
 		return nil
 	}
+	format := c.String("format")
+	if format != conf.FormatJSON && format != conf.FormatYAML && format != conf.FormatTOML {
+		m.xlogger.Errorf("不支持的输出格式:%s", format)
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return nil
+	}
 	print := m.xlogger.Info
 
 	m.logger.PauseLogging()
@@ -30,6 +36,7 @@ func (m *MicroApp) queryConfigAction(c *cli.Context) (err error) {
 	}
 	queryIndex := 0
 	queryList := make(map[int][]byte)
+	overlayList := make(map[int]map[string]string)
 	for i, tp := range m.ServerTypes {
 		mainPath := registry.Join("/", m.PlatName, m.SystemName, tp, m.ClusterName, "conf")
 		buffer, version, err := rgst.GetValue(mainPath)
@@ -41,30 +48,33 @@ func (m *MicroApp) queryConfigAction(c *cli.Context) (err error) {
 			return err
 		}
 		queryIndex++
+		overlayList[queryIndex] = sc.GetOverlaySources(mainPath)
 		if i == 0 {
-			print(getPrintNode(mainPath, queryIndex, 0))
+			print(getPrintNode(mainPath, queryIndex, 0) + getOverlayMark(overlayList[queryIndex]))
 		} else {
-			print(getPrintNode(mainPath, queryIndex, 2))
+			print(getPrintNode(mainPath, queryIndex, 2) + getOverlayMark(overlayList[queryIndex]))
 		}
-		queryList[queryIndex] = buffer
+		queryList[queryIndex] = getPrintRaw(sc, mainPath, sc.GetRaw())
 
 		sc.IterSubConf(func(k string, cn *conf.JSONConf) bool {
 			queryIndex++
-			print(getPrintNode(registry.Join(mainPath, k), queryIndex, -1))
-			queryList[queryIndex] = cn.GetRaw()
+			overlayList[queryIndex] = sc.GetOverlaySources(registry.Join(mainPath, k))
+			print(getPrintNode(registry.Join(mainPath, k), queryIndex, -1) + getOverlayMark(overlayList[queryIndex]))
+			queryList[queryIndex] = getPrintRaw(sc, registry.Join(mainPath, k), cn.GetRaw())
 			return true
 		})
 		if i == len(m.ServerTypes)-1 {
 			index := -1
 			sc.IterVarConf(func(k string, cn *conf.JSONConf) bool {
 				queryIndex++
+				overlayList[queryIndex] = sc.GetOverlaySources(registry.Join("/", m.PlatName, "var", k))
 				if index == -1 {
 					index++
-					print(getPrintNode(registry.Join(m.PlatName, "var", k), queryIndex, 1))
+					print(getPrintNode(registry.Join(m.PlatName, "var", k), queryIndex, 1) + getOverlayMark(overlayList[queryIndex]))
 				} else {
-					print(getPrintNode(registry.Join(m.PlatName, "var", k), queryIndex, -1))
+					print(getPrintNode(registry.Join(m.PlatName, "var", k), queryIndex, -1) + getOverlayMark(overlayList[queryIndex]))
 				}
-				queryList[queryIndex] = cn.GetRaw()
+				queryList[queryIndex] = getPrintRaw(sc, registry.Join("/", m.PlatName, "var", k), cn.GetRaw())
 				return true
 			})
 		}
@@ -81,17 +91,45 @@ func (m *MicroApp) queryConfigAction(c *cli.Context) (err error) {
 		if !ok {
 			continue
 		}
-		data := map[string]interface{}{}
-		if err := json.Unmarshal(content, &data); err != nil {
-			print(string(content))
-			continue
-		}
-		buff, err := json.MarshalIndent(data, "", "    ")
+		buff, err := conf.Convert(content, format)
 		if err != nil {
 			print(string(content))
-			continue
+		} else {
+			print(string(buff))
 		}
-		print(string(buff))
+		printOverlay(print, overlayList[nv])
+	}
+}
+
+//getPrintRaw 获取输出的配置内容,引用密钥的节点输出引用而不是密钥值
+func getPrintRaw(sc *conf.ServerConf, path string, raw []byte) []byte {
+	if buff := sc.GetUnresolvedRaw(path); buff != nil {
+		return buff
+	}
+	return raw
+}
+
+//getOverlayMark 包含本地覆盖配置的节点增加标识
+func getOverlayMark(sources map[string]string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	return " \033[;33m(overlay)\033[0m"
+}
+
+//printOverlay 输出被本地覆盖配置修改的键及来源
+func printOverlay(print func(...interface{}), sources map[string]string) {
+	if len(sources) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(sources))
+	for k := range sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	print("以下配置来自本地覆盖:")
+	for _, k := range keys {
+		print(fmt.Sprintf("  %s <- %s", k, sources[k]))
 	}
 }
 func getPrintNode(path string, index int, f int) string {
@@ -0,0 +1,108 @@
+package parrot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/sereiner/parrot/conf"
+	"github.com/urfave/cli"
+)
+
+func getCryptFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "key-file,k",
+			EnvVar: conf.CryptKeyFileEnv,
+			Usage: `-密钥文件,可选项。未指定时从环境变量` + conf.CryptKeyEnv + `(格式:密钥编号:base64密钥)或默认密钥文件~/.parrot/conf.keys
+	 中获取密钥。该参数可从环境变量中获取，环境变量名为:`,
+		},
+	}
+}
+
+//getCryptInput 获取待加解密内容
+func getCryptInput(c *cli.Context) ([]byte, error) {
+	if c.NArg() > 0 {
+		return []byte(strings.Join(c.Args(), " ")), nil
+	}
+	buff, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(string(buff), "\r\n")), nil
+}
+
+//loadCrypt 指定密钥文件时使用密钥文件中的密钥
+func loadCrypt(c *cli.Context) error {
+	if c.String("key-file") == "" {
+		return nil
+	}
+	crypt, err := conf.LoadAESGCMCrypt(c.String("key-file"))
+	if err != nil {
+		return err
+	}
+	conf.RegisterCrypt(crypt)
+	return nil
+}
+
+func (m *MicroApp) encryptAction(c *cli.Context) (err error) {
+	if err = loadCrypt(c); err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	input, err := getCryptInput(c)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	v, err := conf.Encrypt(input)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	fmt.Println(v)
+	return nil
+}
+
+func (m *MicroApp) decryptAction(c *cli.Context) (err error) {
+	if err = loadCrypt(c); err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	input, err := getCryptInput(c)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	v, err := conf.Decrypt(input)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	fmt.Println(string(v))
+	return nil
+}
+
+func (m *MicroApp) genkeyAction(c *cli.Context) (err error) {
+	if c.NArg() != 1 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return fmt.Errorf("未指定密钥编号")
+	}
+	path := conf.GetCryptKeyFile(c.String("key-file"))
+	keys, err := conf.ReadCryptKeys(path)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	if err = keys.Generate(c.Args().First()); err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	if err = keys.Save(path); err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	m.xlogger.Infof("已生成密钥%s,保存到%s", keys.Current, path)
+	return nil
+}
@@ -3,6 +3,7 @@ package parrot
 
 import (
 	"os"
+	"strings"
 
 	"github.com/sereiner/parrot/conf/creator"
 	"github.com/sereiner/parrot/registry"
@@ -66,9 +67,12 @@ func (m *MicroApp) installAction(c *cli.Context) (err error) {
 		m.xlogger.Error(err)
 		return err
 	}
+	if m.DryRun {
+		return nil
+	}
 
 	//安装配置文件
-	msg, err := m.service.Install(os.Args[2:]...)
+	msg, err := m.service.Install(removeInstallArgs(os.Args[2:])...)
 	if err != nil {
 		m.xlogger.Error(err)
 		return err
@@ -101,6 +105,29 @@ func (m *MicroApp) versionAction(c *cli.Context) (err error) {
 	return nil
 }
 
+//removeInstallArgs 移除仅用于安装配置的参数,其余参数作为服务运行参数
+func removeInstallArgs(args []string) []string {
+	withValue := map[string]bool{"m": true, "mode": true, "a": true, "answers": true}
+	noValue := map[string]bool{"y": true, "non-interactive": true, "dry-run": true}
+	nargs := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name := ""
+		if strings.HasPrefix(args[i], "-") {
+			name = strings.TrimLeft(strings.SplitN(args[i], "=", 2)[0], "-")
+		}
+		switch {
+		case noValue[name]:
+		case withValue[name]:
+			if !strings.Contains(args[i], "=") {
+				i++
+			}
+		default:
+			nargs = append(nargs, args[i])
+		}
+	}
+	return nargs
+}
+
 func (m *MicroApp) install() (err error) {
 	m.logger.PauseLogging()
 	defer m.logger.StartLogging()
@@ -110,10 +137,25 @@ func (m *MicroApp) install() (err error) {
 		return err
 	}
 
+	opts := []creator.Option{creator.WithMode(m.InstallMode)}
+	if m.AnswersFile != "" {
+		answers, err := creator.LoadAnswers(m.AnswersFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, creator.WithAnswers(answers), creator.WithNonInteractive())
+	}
+	if m.NonInteractive {
+		opts = append(opts, creator.WithNonInteractive())
+	}
+	if m.DryRun {
+		opts = append(opts, creator.WithDryRun())
+	}
+
 	//自动创建配置
 	creator := creator.NewCreator(m.PlatName, m.SystemName, m.ServerTypes, m.ClusterName, m.Conf,
 		m.RegistryAddr, rgst,
-		m.xlogger)
+		m.xlogger, opts...)
 	err = creator.Start()
 	if err != nil {
 		return err
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+//CheckTimeout 单次检查的超时时长
+var CheckTimeout = time.Second * 5
+
+//Result 检查项结果
+type Result struct {
+	Name string
+	Err  error
+}
+
+//IChecker 健康检查提供程序
+type IChecker interface {
+	//Live 存活检查,失败时进程需要重启
+	Live() []*Result
+	//Ready 就绪检查,失败时不应接收请求
+	Ready() []*Result
+}
+
+//Server 提供/healthz,/readyz,/livez检查接口
+type Server struct {
+	checker  IChecker
+	server   *http.Server
+	addr     string
+	draining int32
+}
+
+//New 构建健康检查服务器
+func New(addr string, checker IChecker) *Server {
+	s := &Server{addr: addr, checker: checker}
+	s.server = &http.Server{Handler: s.Handler()}
+	return s
+}
+
+//Handler 获取检查接口的http处理程序
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	})
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeResults(w, run(s.checker.Live))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if s.IsDraining() {
+			write(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "draining"})
+			return
+		}
+		writeResults(w, run(s.checker.Ready))
+	})
+	return mux
+}
+
+//Start 启动服务器
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("健康检查服务启动失败:%v", err)
+	}
+	s.addr = l.Addr().String()
+	go s.server.Serve(l)
+	return nil
+}
+
+//GetAddress 获取监听地址
+func (s *Server) GetAddress() string {
+	return s.addr
+}
+
+//Drain 进入下线状态,/readyz返回503
+func (s *Server) Drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+//IsDraining 是否处于下线状态
+func (s *Server) IsDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+//Close 关闭服务器
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+//run 执行检查,超时未完成时返回超时错误
+func run(f func() []*Result) []*Result {
+	ch := make(chan []*Result, 1)
+	go func() {
+		ch <- f()
+	}()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(CheckTimeout):
+		return []*Result{{Name: "timeout", Err: fmt.Errorf("检查超过%v未完成", CheckTimeout)}}
+	}
+}
+
+func writeResults(w http.ResponseWriter, results []*Result) {
+	status := "ok"
+	code := http.StatusOK
+	checks := make(map[string]string, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			status = "fail"
+			code = http.StatusServiceUnavailable
+			checks[r.Name] = r.Err.Error()
+			continue
+		}
+		checks[r.Name] = "ok"
+	}
+	write(w, code, map[string]interface{}{"status": status, "checks": checks})
+}
+
+func write(w http.ResponseWriter, code int, data map[string]interface{}) {
+	buff, _ := json.Marshal(data)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	w.Write(buff)
+}
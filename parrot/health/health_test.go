@@ -0,0 +1,86 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sereiner/library/ut"
+)
+
+type checker struct {
+	ready []*Result
+	delay time.Duration
+}
+
+func (c *checker) Live() []*Result {
+	return []*Result{{Name: "parrot"}}
+}
+
+func (c *checker) Ready() []*Result {
+	time.Sleep(c.delay)
+	return c.ready
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	data := make(map[string]interface{})
+	ut.Expect(t, json.Unmarshal(w.Body.Bytes(), &data), nil)
+	return w.Code, data
+}
+
+func TestHealth(t *testing.T) {
+	c := &checker{ready: []*Result{{Name: "registry"}, {Name: "api"}}}
+	s := New("127.0.0.1:0", c)
+	h := s.Handler()
+
+	code, data := get(t, h, "/healthz")
+	ut.Expect(t, code, 200)
+	ut.Expect(t, data["status"], "ok")
+
+	code, _ = get(t, h, "/livez")
+	ut.Expect(t, code, 200)
+
+	code, data = get(t, h, "/readyz")
+	ut.Expect(t, code, 200)
+	ut.Expect(t, data["checks"].(map[string]interface{})["api"], "ok")
+
+	c.ready = append(c.ready, &Result{Name: "mqc", Err: errors.New("未连接到消息服务器")})
+	code, data = get(t, h, "/readyz")
+	ut.Expect(t, code, 503)
+	ut.Expect(t, data["status"], "fail")
+	ut.Expect(t, data["checks"].(map[string]interface{})["mqc"], "未连接到消息服务器")
+
+	c.ready = c.ready[:2]
+	s.Drain()
+	code, data = get(t, h, "/readyz")
+	ut.Expect(t, code, 503)
+	ut.Expect(t, data["status"], "draining")
+	code, _ = get(t, h, "/healthz")
+	ut.Expect(t, code, 200)
+}
+
+func TestReadyTimeout(t *testing.T) {
+	timeout := CheckTimeout
+	CheckTimeout = time.Millisecond * 50
+	defer func() { CheckTimeout = timeout }()
+
+	c := &checker{ready: []*Result{{Name: "api"}}, delay: time.Millisecond * 200}
+	code, data := get(t, New("127.0.0.1:0", c).Handler(), "/readyz")
+	ut.Expect(t, code, 503)
+	ut.Expect(t, data["checks"].(map[string]interface{})["timeout"] != nil, true)
+}
+
+func TestStart(t *testing.T) {
+	s := New("127.0.0.1:0", &checker{})
+	ut.Expect(t, s.Start(), nil)
+	defer s.Close()
+	resp, err := http.Get("http://" + s.GetAddress() + "/healthz")
+	ut.Expect(t, err, nil)
+	resp.Body.Close()
+	ut.Expect(t, resp.StatusCode, 200)
+}
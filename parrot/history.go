@@ -0,0 +1,169 @@
+package parrot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sereiner/library/types"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/conf/diff"
+	"github.com/sereiner/parrot/conf/history"
+	"github.com/sereiner/parrot/registry"
+	"github.com/urfave/cli"
+)
+
+//getNodePath 获取节点完整路径,非/开头的路径为平台下的相对路径
+func (m *MicroApp) getNodePath(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	return registry.Join("/", m.PlatName, path)
+}
+
+func (m *MicroApp) checkHistoryInput(c *cli.Context, nargs int) (err error) {
+	if err = m.checkInput(c); err == nil && c.NArg() < nargs {
+		err = fmt.Errorf("参数不足")
+	}
+	if err != nil {
+		cli.ErrWriter.Write([]byte("  " + err.Error() + "\n\n"))
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return err
+	}
+	return nil
+}
+
+func (m *MicroApp) historyListAction(c *cli.Context) (err error) {
+	if err = m.checkHistoryInput(c, 0); err != nil {
+		return err
+	}
+	rgst, err := registry.NewRegistryWithAddress(m.RegistryAddr, m.logger)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	h := history.New(rgst)
+	if c.NArg() == 0 {
+		paths, err := h.Nodes(m.PlatName)
+		if err != nil {
+			m.xlogger.Error(err)
+			return err
+		}
+		for _, path := range paths {
+			m.xlogger.Info("\t\t", path)
+		}
+		m.xlogger.Infof("共%d个节点有历史记录", len(paths))
+		return nil
+	}
+	path := m.getNodePath(c.Args().First())
+	records, err := h.List(path)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	for _, r := range records {
+		m.xlogger.Infof("\t\t%d\t%s\t%s\t%s", r.Revision, r.Time.Format("2006-01-02 15:04:05"), r.User, r.Action)
+	}
+	m.xlogger.Infof("%s共%d个历史版本", path, len(records))
+	return nil
+}
+
+func (m *MicroApp) historyDiffAction(c *cli.Context) (err error) {
+	if err = m.checkHistoryInput(c, 2); err != nil {
+		return err
+	}
+	rgst, err := registry.NewRegistryWithAddress(m.RegistryAddr, m.logger)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	h := history.New(rgst)
+	path := m.getNodePath(c.Args().Get(0))
+	old, err := h.Get(path, types.GetInt(c.Args().Get(1), -1))
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	//未指定第二个版本时与当前配置比较
+	newName, newData := fmt.Sprintf("%s (current)", path), ""
+	if c.NArg() > 2 {
+		r, err := h.Get(path, types.GetInt(c.Args().Get(2), -1))
+		if err != nil {
+			m.xlogger.Error(err)
+			return err
+		}
+		newName, newData = fmt.Sprintf("%s (%d)", path, r.Revision), r.Data
+	} else {
+		data, _, err := rgst.GetValue(path)
+		if err != nil {
+			m.xlogger.Error(err)
+			return err
+		}
+		newData = string(data)
+	}
+	v := diff.Unified(fmt.Sprintf("%s (%d)", path, old.Revision), newName, formatHistory(old.Data), formatHistory(newData))
+	if v == "" {
+		m.xlogger.Info("配置无变化")
+		return nil
+	}
+	fmt.Print(v)
+	return nil
+}
+
+func (m *MicroApp) historyRollbackAction(c *cli.Context) (err error) {
+	if err = m.checkHistoryInput(c, 2); err != nil {
+		return err
+	}
+	rgst, err := registry.NewRegistryWithAddress(m.RegistryAddr, m.logger)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	path := m.getNodePath(c.Args().Get(0))
+	r, err := history.New(rgst).Rollback(path, types.GetInt(c.Args().Get(1), -1))
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	if r == nil {
+		m.xlogger.Infof("%s与版本%s相同,无需回滚", path, c.Args().Get(1))
+		return nil
+	}
+	m.xlogger.Infof("%s已回滚到版本%s(新版本:%d)", path, c.Args().Get(1), r.Revision)
+
+	//服务器只监控主配置,子配置与var配置回滚后需通知主配置重新加载
+	for _, p := range m.getNotifyPaths(rgst, path) {
+		if err = history.Touch(rgst, p); err != nil {
+			m.xlogger.Errorf("通知%s重新加载失败:%v", p, err)
+			continue
+		}
+		m.xlogger.Info("\t\t通知重新加载:", p)
+	}
+	return nil
+}
+
+//getNotifyPaths 获取节点回滚后需通知重新加载的主配置路径
+func (m *MicroApp) getNotifyPaths(rgst registry.IRegistry, path string) []string {
+	sections := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(sections) == 6 && sections[4] == "conf":
+		return []string{"/" + strings.Join(sections[:5], "/")}
+	case len(sections) > 1 && sections[1] == "var":
+		paths := make([]string, 0, len(m.ServerTypes))
+		for _, tp := range m.ServerTypes {
+			p := registry.Join("/", m.PlatName, m.SystemName, tp, m.ClusterName, "conf")
+			if b, _ := rgst.Exists(p); b {
+				paths = append(paths, p)
+			}
+		}
+		return paths
+	}
+	return nil
+}
+
+//formatHistory 解密并格式化配置内容
+func formatHistory(data string) string {
+	if v, err := conf.Decrypt([]byte(data)); err == nil {
+		data = string(v)
+	}
+	return diff.FormatJSON(data)
+}
@@ -16,7 +16,9 @@ import (
 	_ "github.com/sereiner/library/queue/redis"
 	_ "github.com/sereiner/library/queue/xmq"
 	_ "github.com/sereiner/parrot/engines"
+	_ "github.com/sereiner/parrot/registry/etcd"
 	_ "github.com/sereiner/parrot/registry/local"
+	_ "github.com/sereiner/parrot/registry/mem"
 	_ "github.com/sereiner/parrot/registry/zookeeper"
 	_ "github.com/sereiner/parrot/rpc"
 	_ "github.com/sereiner/parrot/servers/cron"
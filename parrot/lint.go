@@ -0,0 +1,67 @@
+package parrot
+
+import (
+	"fmt"
+
+	"github.com/sereiner/parrot/component"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/conf/lint"
+	"github.com/sereiner/parrot/registry"
+	"github.com/urfave/cli"
+)
+
+func (m *MicroApp) lintAction(c *cli.Context) (err error) {
+	if err = m.checkInput(c); err != nil {
+		cli.ErrWriter.Write([]byte("  " + err.Error() + "\n\n"))
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return err
+	}
+	m.logger.PauseLogging()
+	defer m.logger.StartLogging()
+	rgst, err := registry.NewRegistryWithAddress(m.RegistryAddr, m.logger)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	issues := make([]*lint.Issue, 0, 1)
+	for _, tp := range m.ServerTypes {
+		mainPath := registry.Join("/", m.PlatName, m.SystemName, tp, m.ClusterName, "conf")
+		issues = append(issues, m.lintServer(rgst, mainPath, tp)...)
+	}
+	for _, issue := range issues {
+		m.xlogger.Error("\t\t", issue)
+	}
+	if len(issues) > 0 {
+		return cli.NewExitError(fmt.Sprintf("配置检查未通过,共%d个问题", len(issues)), 1)
+	}
+	m.xlogger.Info("配置检查通过")
+	return nil
+}
+
+//lintServer 检查服务器配置,无法加载配置时作为问题返回
+func (m *MicroApp) lintServer(rgst registry.IRegistry, mainPath string, tp string) []*lint.Issue {
+	if b, err := rgst.Exists(mainPath); err != nil || !b {
+		return []*lint.Issue{{Path: mainPath, Message: "配置不存在"}}
+	}
+	data, version, err := rgst.GetValue(mainPath)
+	if err != nil {
+		return []*lint.Issue{{Path: mainPath, Message: err.Error()}}
+	}
+	sc, err := conf.NewServerConf(mainPath, data, version, rgst)
+	if err != nil {
+		return []*lint.Issue{{Path: mainPath, Message: err.Error()}}
+	}
+	return lint.Lint(sc, m.getLintServices(tp))
+}
+
+//getLintServices 获取服务器类型可提供的服务
+func (m *MicroApp) getLintServices(tp string) []string {
+	services := make([]string, 0, 4)
+	all := m.IComponentRegistry.GetServices()
+	for _, group := range component.GetGroupName(tp) {
+		for name := range all[group] {
+			services = append(services, name)
+		}
+	}
+	return services
+}
@@ -24,9 +24,14 @@ type option struct {
 	ClusterName        string `json:"--cluster" valid:"ascii,required"`
 	IsDebug            bool
 	Trace              string
+	HealthAddr         string
 	remoteLogger       bool
 	RemoteLogger       bool
 	RemoteQueryService bool
+	InstallMode        string
+	AnswersFile        string
+	NonInteractive     bool
+	DryRun             bool
 	PbFunc             func(component.IContainer, *grpc.Server)
 }
 
@@ -116,6 +121,13 @@ func WithProduct() Option {
 	}
 }
 
+//WithHealth 启用健康检查服务,addr为监听地址,如:":10190"
+func WithHealth(addr string) Option {
+	return func(o *option) {
+		o.HealthAddr = addr
+	}
+}
+
 //WithRemoteLogger 设置产品模式
 func WithRemoteLogger() Option {
 	return func(o *option) {
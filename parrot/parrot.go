@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/sereiner/parrot/component"
+	"github.com/sereiner/parrot/parrot/health"
 	"github.com/sereiner/parrot/parrot/rpclog"
 	"github.com/sereiner/parrot/servers"
 
@@ -41,13 +42,15 @@ type parrot struct {
 	cHandler       component.IComponentHandler
 	rspServer      *rspServer
 	trace          string
+	health         string
+	healthServer   *health.Server
 	done           bool
 	remoteLogger   bool
 	PbFunc         func(component.IContainer, *grpc.Server)
 }
 
 //Newparrot 创建parrot服务器
-func Newparrot(appName string, platName string, systemName string, serverTypes []string, clusterName string, trace string, registryAddr string, isDebug bool, remoteLogger bool, logger *logger.Logger, r component.IComponentHandler, PbFunc func(component.IContainer, *grpc.Server)) *parrot {
+func Newparrot(appName string, platName string, systemName string, serverTypes []string, clusterName string, trace string, healthAddr string, registryAddr string, isDebug bool, remoteLogger bool, logger *logger.Logger, r component.IComponentHandler, PbFunc func(component.IContainer, *grpc.Server)) *parrot {
 	servers.IsDebug = isDebug
 	return &parrot{
 		appName:        appName,
@@ -66,6 +69,7 @@ func Newparrot(appName string, platName string, systemName string, serverTypes [
 		registryAddr: registryAddr,
 		remoteLogger: remoteLogger,
 		trace:        trace,
+		health:       healthAddr,
 		PbFunc:       PbFunc,
 	}
 }
@@ -95,17 +99,35 @@ func (h *parrot) Start() (s string, err error) {
 
 	go h.freeMemory()
 
+	//启动健康检查服务
+	if err = h.startHealth(); err != nil {
+		return "", err
+	}
+
+	//由平滑重启启动时通知父进程
+	go h.notifyReady()
+
 	//堵塞当前进程，等服务结束
-	signal.Notify(h.interrupt, os.Interrupt, os.Kill, syscall.SIGTERM) //, syscall.SIGUSR1) //9:kill/SIGKILL,15:SIGTEM,20,SIGTOP 2:interrupt/syscall.SIGINT
+	signal.Notify(h.interrupt, signals...)
 LOOP:
 	for {
 		select {
-		case <-h.interrupt:
+		case sig := <-h.interrupt:
+			if sig == restartSignal && !h.done {
+				if err := h.restart(); err != nil {
+					h.logger.Errorf("平滑重启失败,继续使用当前进程:%v", err)
+					continue
+				}
+			}
 			h.done = true
 			break LOOP
 		}
 	}
 	h.logger.Infof("%s 正在退出...", h.appName)
+	if h.healthServer != nil {
+		h.healthServer.Drain()
+		defer h.healthServer.Close()
+	}
 	h.rspServer.Shutdown()
 	return fmt.Sprintf("%s 已安全退出", h.appName), nil
 }
@@ -138,6 +160,9 @@ func (h *parrot) startWatch() (err error) {
 
 	//循环接收服务变更新通知
 	go h.loopRecvNotify()
+
+	//定时检查配置引用的密钥是否更新
+	go h.rspServer.loopCheckSecret(h.closeChan)
 	return nil
 }
 
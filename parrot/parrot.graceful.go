@@ -0,0 +1,49 @@
+package parrot
+
+import (
+	"time"
+
+	"github.com/sereiner/parrot/servers/pkg/graceful"
+)
+
+//readyCheckSpan 新进程就绪检查间隔
+var readyCheckSpan = time.Millisecond * 500
+
+//restart 平滑重启,启动新进程并传入监听端口,新进程就绪后当前进程退出
+func (h *parrot) restart() error {
+	h.logger.Infof("%s 开始平滑重启...", h.appName)
+	if err := graceful.Restart(graceful.ReadyTimeout); err != nil {
+		return err
+	}
+	h.logger.Info("新进程已就绪,停止当前服务")
+	return nil
+}
+
+//notifyReady 由平滑重启启动时,所有服务器就绪后通知父进程
+func (h *parrot) notifyReady() {
+	if !graceful.IsChild() {
+		return
+	}
+	for {
+		select {
+		case <-h.closeChan:
+			return
+		case <-time.After(readyCheckSpan):
+			if h.isReady() {
+				if err := graceful.NotifyReady(); err != nil {
+					h.logger.Errorf("无法通知父进程:%v", err)
+				}
+				return
+			}
+		}
+	}
+}
+
+func (h *parrot) isReady() bool {
+	for _, r := range h.Ready() {
+		if r.Err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package parrot
+
+import (
+	"fmt"
+
+	"github.com/sereiner/parrot/parrot/health"
+	"github.com/sereiner/parrot/servers"
+)
+
+//startHealth 启动健康检查服务
+func (h *parrot) startHealth() error {
+	if h.health == "" {
+		return nil
+	}
+	h.healthServer = health.New(h.health, h)
+	if err := h.healthServer.Start(); err != nil {
+		return err
+	}
+	h.logger.Infof("健康检查服务启动成功(http://%s/readyz)", h.healthServer.GetAddress())
+	return nil
+}
+
+//Live 存活检查,配置变更处理超过等待请求完成的最长时间时检查失败
+func (h *parrot) Live() []*health.Result {
+	if h.done || h.rspServer == nil {
+		return []*health.Result{{Name: "parrot"}}
+	}
+	if d := h.rspServer.getChangeBlocked(); d > 0 {
+		return []*health.Result{{Name: "parrot", Err: fmt.Errorf("配置变更处理已阻塞%v", d)}}
+	}
+	return []*health.Result{{Name: "parrot"}}
+}
+
+//Ready 就绪检查,包括注册中心连接,服务器状态与服务器就绪检查
+func (h *parrot) Ready() []*health.Result {
+	if h.done || h.rspServer == nil {
+		return []*health.Result{{Name: "parrot", Err: fmt.Errorf("服务未启动或正在退出")}}
+	}
+	results := make([]*health.Result, 0, 4)
+	rs := &health.Result{Name: "registry"}
+	if ok, err := h.registry.Exists(h.systemRootName); err != nil {
+		rs.Err = fmt.Errorf("注册中心不可用:%v", err)
+	} else if !ok {
+		rs.Err = fmt.Errorf("%s未配置", h.systemRootName)
+	}
+	results = append(results, rs)
+	return append(results, h.rspServer.Ready()...)
+}
+
+//Ready 检查所有服务器的状态与就绪检查项
+func (s *rspServer) Ready() []*health.Result {
+	s.mu.Lock()
+	current := make(map[string]*server, len(s.servers))
+	for path, server := range s.servers {
+		current[path] = server
+	}
+	s.mu.Unlock()
+
+	if len(current) == 0 {
+		return []*health.Result{{Name: "servers", Err: fmt.Errorf("无运行中的服务器")}}
+	}
+	results := make([]*health.Result, 0, len(current))
+	for _, server := range current {
+		r := &health.Result{Name: server.cnf.GetServerType()}
+		if status := server.GetStatus(); status != servers.ST_RUNNING && status != servers.ST_PAUSE {
+			r.Err = fmt.Errorf("服务器状态:%s", status)
+		} else {
+			r.Err = server.Ready()
+		}
+		results = append(results, r)
+	}
+	return results
+}
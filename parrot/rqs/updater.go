@@ -19,6 +19,7 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/context"
 	"github.com/sereiner/parrot/registry"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 type updater struct {
@@ -187,9 +188,19 @@ func UpdateNow(pkg *conf.Package, logger *logger.Logger, closeFunc func()) (err
 		err = fmt.Errorf("更新失败,已回滚(err:%v)", err)
 		return
 	}
-	logger.Info("更新成功，停止所有服务，并准备重启")
-	closeFunc()
-	return restart()
+	logger.Info("更新成功，准备重启")
+	go func() {
+		//平滑重启,新进程就绪后再停止当前服务
+		if err := graceful.Restart(graceful.ReadyTimeout); err != nil {
+			logger.Warnf("平滑重启失败,停止所有服务后重启:%v", err)
+			closeFunc()
+			restart()
+			return
+		}
+		logger.Info("新进程已就绪，停止所有服务")
+		closeFunc()
+	}()
+	return nil
 }
 
 //Restart 重启当前服务
@@ -5,25 +5,37 @@ import (
 	"google.golang.org/grpc"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/servers/pkg/drain"
 
 	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/conf/history"
 	"github.com/sereiner/parrot/registry"
 	"github.com/sereiner/parrot/registry/watcher"
 )
 
+//secretCheckSpan 密钥更新检查间隔
+var secretCheckSpan = time.Second * 30
+
+//shutdownMargin 等待处理中的请求完成后,注销服务与关闭组件的最长时间
+var shutdownMargin = time.Second * 10
+
 type rspServer struct {
-	servers      map[string]*server
-	mu           sync.Mutex
-	registry     registry.IRegistry
-	registryAddr string
-	logger       *logger.Logger
-	handler      component.IComponentHandler
-	PbFunc       func(component.IContainer, *grpc.Server)
-	done         bool
+	changeStart   int64 //当前配置变更的开始时间(纳秒),无变更处理时为0
+	changeTimeout int64 //当前配置变更允许的最长处理时间(纳秒)
+	servers       map[string]*server
+	mu            sync.Mutex
+	registry      registry.IRegistry
+	registryAddr  string
+	logger        *logger.Logger
+	handler       component.IComponentHandler
+	history       *history.History
+	PbFunc        func(component.IContainer, *grpc.Server)
+	done          bool
 }
 
 func newRspServer(registryAddr string, registry registry.IRegistry, handler component.IComponentHandler, f func(component.IContainer, *grpc.Server), logger *logger.Logger) *rspServer {
@@ -32,6 +44,7 @@ func newRspServer(registryAddr string, registry registry.IRegistry, handler comp
 		registryAddr: registryAddr,
 		servers:      make(map[string]*server),
 		handler:      handler,
+		history:      history.NewApplier(registry),
 		PbFunc:       f,
 		logger:       logger,
 	}
@@ -47,12 +60,18 @@ func (s *rspServer) Change(u *watcher.ContentChangeArgs) {
 		func() {
 			s.mu.Lock()
 			defer s.mu.Unlock()
+			defer s.beginChange(u.Path)()
 			//初始化服务器配置
 			conf, err := conf.NewServerConf(u.Path, u.Content, u.Version, s.registry)
 			if err != nil {
 				s.logger.Error(err)
 				return
 			}
+			//配置校验失败时保持当前配置
+			if err = conf.Validate(); err != nil {
+				s.logger.Errorf("配置校验失败,未应用变更:%v", err)
+				return
+			}
 			conf.Set("__component_handler_", s.handler)
 			if _, ok := s.servers[u.Path]; !ok {
 				//添加新服务器
@@ -69,6 +88,7 @@ func (s *rspServer) Change(u *watcher.ContentChangeArgs) {
 				}
 				s.servers[u.Path] = server
 				server.logger.Infof("服务启动成功(%s,%s,%d)", strings.ToUpper(conf.GetServerType()), server.GetAddress(), len(server.GetServices()))
+				s.recordHistory(conf, u)
 			} else {
 				//修改服务器
 				server := s.servers[u.Path]
@@ -81,6 +101,7 @@ func (s *rspServer) Change(u *watcher.ContentChangeArgs) {
 						} else {
 							server.logger.Info("配置更新成功")
 						}
+						s.recordHistory(conf, u)
 					}
 				} else {
 					server.logger.Warnf("服务器配置为:stop")
@@ -98,6 +119,7 @@ func (s *rspServer) Change(u *watcher.ContentChangeArgs) {
 		func() {
 			s.mu.Lock()
 			defer s.mu.Unlock()
+			defer s.beginChange(u.Path)()
 			if server, ok := s.servers[u.Path]; ok {
 				server.logger.Errorf("%s配置已删除", u.Path)
 				server.Shutdown()
@@ -109,23 +131,105 @@ func (s *rspServer) Change(u *watcher.ContentChangeArgs) {
 	}
 }
 
-//Change 服务器发生变更
+//beginChange 记录配置变更的开始时间,最长处理时间为服务器等待请求完成的时间加shutdownMargin,
+//返回的函数用于结束记录;须在持有s.mu时调用
+func (s *rspServer) beginChange(path string) func() {
+	timeout := drain.DefaultTimeout
+	if server, ok := s.servers[path]; ok {
+		timeout = drain.GetTimeout(server.cnf)
+	}
+	atomic.StoreInt64(&s.changeTimeout, int64(timeout+shutdownMargin))
+	atomic.StoreInt64(&s.changeStart, time.Now().UnixNano())
+	return func() {
+		atomic.StoreInt64(&s.changeStart, 0)
+	}
+}
+
+//getChangeBlocked 获取配置变更处理超出最长处理时间后已持续的时长,未超时返回0
+func (s *rspServer) getChangeBlocked() time.Duration {
+	start := atomic.LoadInt64(&s.changeStart)
+	if start == 0 {
+		return 0
+	}
+	elapsed := time.Since(time.Unix(0, start))
+	if elapsed <= time.Duration(atomic.LoadInt64(&s.changeTimeout)) {
+		return 0
+	}
+	return elapsed
+}
+
+//recordHistory 记录已应用的配置
+func (s *rspServer) recordHistory(cnf conf.IServerConf, u *watcher.ContentChangeArgs) {
+	records, err := s.history.RecordConf(cnf, u.Content, u.Version)
+	if err != nil {
+		s.logger.Warnf("配置历史记录失败:%v", err)
+	}
+	for _, r := range records {
+		s.logger.Debugf("记录配置历史:%s(版本:%d)", r.Path, r.Revision)
+	}
+}
+
+//loopCheckSecret 定时检查配置引用的密钥,密钥更新后按配置变更重新加载服务器
+func (s *rspServer) loopCheckSecret(closeChan chan struct{}) {
+	for {
+		select {
+		case <-closeChan:
+			return
+		case <-time.After(secretCheckSpan):
+			if s.done {
+				return
+			}
+			for _, path := range s.getSecretChanged() {
+				data, version, err := s.registry.GetValue(path)
+				if err != nil {
+					s.logger.Errorf("获取配置失败:%s(%v)", path, err)
+					continue
+				}
+				s.logger.Infof("%s引用的密钥已更新,重新加载配置", path)
+				s.Change(&watcher.ContentChangeArgs{OP: watcher.CHANGE, Path: path, Content: data, Version: version})
+			}
+		}
+	}
+}
+
+func (s *rspServer) getSecretChanged() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make([]string, 0, 1)
+	for path, server := range s.servers {
+		if server.cnf.IsSecretChanged() {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+//Shutdown 关闭所有服务器,各服务器并行下线并等待处理中的请求完成
 func (s *rspServer) Shutdown() {
 	s.done = true
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	var wg sync.WaitGroup
+	timeout := time.Duration(0)
+	for _, srv := range s.servers {
+		if t := drain.GetTimeout(srv.cnf); t > timeout {
+			timeout = t
+		}
+		wg.Add(1)
+		go func(srv *server) {
+			defer wg.Done()
+			srv.Shutdown()
+		}(srv)
+	}
 	cl := make(chan struct{})
 	go func() {
-		for _, server := range s.servers {
-			server.Shutdown()
-		}
+		wg.Wait()
 		close(cl)
 	}()
+	timeout += shutdownMargin
 	select {
-	case <-time.After(time.Second * 30):
-		return
+	case <-time.After(timeout):
+		s.logger.Warnf("服务器未在%v内完成关闭", timeout)
 	case <-cl:
-		return
 	}
-
 }
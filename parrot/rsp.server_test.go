@@ -0,0 +1,48 @@
+package parrot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/component"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/registry/mem"
+	"github.com/sereiner/parrot/registry/watcher"
+	_ "github.com/sereiner/parrot/servers/cron"
+)
+
+func TestChangeSecretRotate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "parrot-secret")
+	defer os.RemoveAll(dir)
+	conf.RegisterSecretProvider(conf.NewFileSecret(dir))
+	defer conf.RegisterSecretProvider(conf.NewFileSecret(""))
+	os.MkdirAll(filepath.Join(dir, "mq"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "mq", "password"), []byte("123456"), 0600)
+
+	rgst := mem.New("TestChangeSecretRotate")
+	defer rgst.Close()
+	path := "/test/order/cron/t/conf"
+	rgst.CreatePersistentNode(path, `{"status":"start"}`)
+	rgst.CreatePersistentNode(path+"/app", `{"password":"${secret:mq/password}"}`)
+
+	s := newRspServer("mem://TestChangeSecretRotate", rgst, component.NewServiceRegistry(), nil, logger.New("test"))
+	defer s.Shutdown()
+	data, version, _ := rgst.GetValue(path)
+	s.Change(&watcher.ContentChangeArgs{OP: watcher.ADD, Path: path, Content: data, Version: version})
+	ut.Expect(t, len(s.servers), 1)
+	ut.Expect(t, s.getSecretChanged(), []string{})
+
+	//密钥更新后按相同版本号重新加载,app未绑定时重启服务器
+	ioutil.WriteFile(filepath.Join(dir, "mq", "password"), []byte("654321"), 0600)
+	ut.Expect(t, s.getSecretChanged(), []string{path})
+	s.Change(&watcher.ContentChangeArgs{OP: watcher.CHANGE, Path: path, Content: data, Version: version})
+	ut.Expect(t, s.servers[path].Restarted(), true)
+	app, err := s.servers[path].cnf.GetSubConf("app")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, app.GetString("password"), "654321")
+	ut.Expect(t, s.getSecretChanged(), []string{})
+}
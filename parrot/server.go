@@ -54,12 +54,25 @@ func (h *server) Start() (err error) {
 		return err
 	}
 	h.startTime = time.Now()
+	h.notifyAppConf(h.cnf)
 	return nil
 }
 
 //Notify 配置发生变化通知服务器变更
 func (h *server) Notify(cnf conf.IServerConf) error {
-	return h.server.Notify(cnf)
+	if err := h.server.Notify(cnf); err != nil {
+		return err
+	}
+	h.cnf = cnf
+	h.notifyAppConf(cnf)
+	return nil
+}
+
+//notifyAppConf 更新app配置绑定,app配置变更不再重启服务器
+func (h *server) notifyAppConf(cnf conf.IServerConf) {
+	if err := conf.NotifyAppConf(cnf); err != nil {
+		h.logger.Warnf("app配置更新失败:%v", err)
+	}
 }
 
 //GetStatus 获取当前服务状态
@@ -77,6 +90,11 @@ func (h *server) GetServices() map[string][]string {
 	return h.server.GetServices()
 }
 
+//Ready 检查服务器是否就绪
+func (h *server) Ready() error {
+	return h.server.Ready()
+}
+
 //Restarted 服务器是否已重启
 func (h *server) Restarted() bool {
 	return h.server.Restarted()
@@ -87,4 +105,5 @@ func (h *server) Shutdown() {
 	if h.server != nil {
 		h.server.Shutdown()
 	}
+	conf.RemoveAppConf(h.cnf)
 }
@@ -0,0 +1,14 @@
+// +build !windows
+
+package parrot
+
+import (
+	"os"
+	"syscall"
+)
+
+//restartSignal 平滑重启信号
+var restartSignal os.Signal = syscall.SIGUSR2
+
+//signals 需要处理的信号,9:kill/SIGKILL,15:SIGTEM,2:interrupt/syscall.SIGINT,SIGUSR2:平滑重启
+var signals = []os.Signal{os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGUSR2}
@@ -0,0 +1,12 @@
+package parrot
+
+import (
+	"os"
+	"syscall"
+)
+
+//restartSignal windows不支持平滑重启
+var restartSignal os.Signal
+
+//signals 需要处理的信号,9:kill/SIGKILL,15:SIGTEM,2:interrupt/syscall.SIGINT
+var signals = []os.Signal{os.Interrupt, os.Kill, syscall.SIGTERM}
@@ -0,0 +1,76 @@
+package parrot
+
+import (
+	"fmt"
+
+	"github.com/sereiner/parrot/registry"
+	"github.com/sereiner/parrot/registry/snapshot"
+	"github.com/urfave/cli"
+)
+
+func (m *MicroApp) exportAction(c *cli.Context) (err error) {
+	if err = m.checkInput(c); err == nil && c.String("output") == "" {
+		err = fmt.Errorf("未指定导出路径")
+	}
+	if err != nil {
+		cli.ErrWriter.Write([]byte("  " + err.Error() + "\n\n"))
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return err
+	}
+	rgst, err := registry.NewRegistryWithAddress(m.RegistryAddr, m.logger)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	s, err := snapshot.Export(rgst, m.PlatName, m.SystemName, m.ServerTypes, m.ClusterName)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	if err = s.Save(c.String("output")); err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	for _, n := range s.Nodes {
+		m.xlogger.Info("\t\t导出配置:", n.Path)
+	}
+	m.xlogger.Infof("共导出%d个配置到%s", len(s.Nodes), c.String("output"))
+	return nil
+}
+
+func (m *MicroApp) importAction(c *cli.Context) (err error) {
+	if err = m.checkInput(c); err == nil && c.String("input") == "" {
+		err = fmt.Errorf("未指定导入路径")
+	}
+	if err != nil {
+		cli.ErrWriter.Write([]byte("  " + err.Error() + "\n\n"))
+		cli.ShowCommandHelp(c, c.Command.Name)
+		return err
+	}
+	s, err := snapshot.Load(c.String("input"))
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	rgst, err := registry.NewRegistryWithAddress(m.RegistryAddr, m.logger)
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	records, err := s.Import(rgst, m.PlatName, m.SystemName, m.ClusterName, c.Bool("cover"))
+	for _, r := range records {
+		switch r.Action {
+		case snapshot.ActionCreate:
+			m.xlogger.Info("\t\t创建配置:", r.Path)
+		case snapshot.ActionUpdate:
+			m.xlogger.Info("\t\t修改配置:", r.Path)
+		default:
+			m.xlogger.Info("\t\t跳过配置:", r.Path)
+		}
+	}
+	if err != nil {
+		m.xlogger.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,501 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/library/registry"
+	r "github.com/sereiner/parrot/registry"
+)
+
+var _ r.IRegistry = &client{}
+
+//TIMEOUT 单次操作etcd服务器的超时时间
+var TIMEOUT = time.Second * 3
+
+//TTL 临时节点租约有效期(秒),进程异常退出后临时节点最多保留TTL秒
+var TTL int64 = 10
+
+var (
+	//ErrClientConnClosing 连接已关闭
+	ErrClientConnClosing = errors.New("etcd: the client connection is closing")
+	//ErrNodeDeleted 监控的节点已删除
+	ErrNodeDeleted = errors.New("etcd: node has been deleted")
+)
+
+//seqLen 顺序节点序号长度,与zookeeper保持一致
+const seqLen = 10
+
+//client 基于etcd v3的注册中心客户端
+type client struct {
+	conn      *clientv3.Client
+	logger    logger.ILogging
+	leaseID   clientv3.LeaseID
+	leaseLock sync.Mutex
+	closeCh   chan struct{}
+	once      sync.Once
+	done      int32
+}
+
+func newClient(servers []string, u string, p string, log logger.ILogging) (*client, error) {
+	conn, err := clientv3.New(clientv3.Config{
+		Endpoints:   servers,
+		DialTimeout: TIMEOUT,
+		Username:    u,
+		Password:    p,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		conn:    conn,
+		logger:  log,
+		closeCh: make(chan struct{}),
+	}, nil
+}
+
+func (c *client) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), TIMEOUT)
+}
+
+//Exists 检查节点是否存在,节点本身不存在但包含子节点时也视为存在
+func (c *client) Exists(path string) (bool, error) {
+	if c.isDone() {
+		return false, ErrClientConnClosing
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.conn.Get(ctx, path, clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+	if resp.Count > 0 {
+		return true, nil
+	}
+	resp, err = c.conn.Get(ctx, dirPrefix(path), clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+	return resp.Count > 0, nil
+}
+
+//GetValue 获取节点的值
+func (c *client) GetValue(path string) (data []byte, version int32, err error) {
+	if c.isDone() {
+		return nil, 0, ErrClientConnClosing
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.conn.Get(ctx, path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get node:%s error(err:%v)", path, err)
+	}
+	if len(resp.Kvs) == 0 {
+		if b, _ := c.Exists(path); b {
+			return []byte{}, 0, nil
+		}
+		return nil, 0, fmt.Errorf("node(%s) is not exist", path)
+	}
+	return resp.Kvs[0].Value, int32(resp.Kvs[0].Version), nil
+}
+
+//GetChildren 获取子节点名称列表,版本号为父节点的数据版本号
+func (c *client) GetChildren(path string) (paths []string, version int32, err error) {
+	if c.isDone() {
+		return nil, 0, ErrClientConnClosing
+	}
+	if b, err := c.Exists(path); !b || err != nil {
+		return nil, 0, fmt.Errorf("node(%s) is not exist", path)
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.conn.Txn(ctx).Then(
+		clientv3.OpGet(path),
+		clientv3.OpGet(dirPrefix(path), clientv3.WithPrefix(), clientv3.WithKeysOnly()),
+	).Commit()
+	if err != nil {
+		return nil, 0, fmt.Errorf("get node(%s) children error(err:%v)", path, err)
+	}
+	if kvs := resp.Responses[0].GetResponseRange().Kvs; len(kvs) > 0 {
+		version = int32(kvs[0].Version)
+	}
+	return children(path, resp.Responses[1].GetResponseRange().Kvs), version, nil
+}
+
+//Update 更新节点的值,version小于0时不检查版本号
+func (c *client) Update(path string, data string, version int32) (err error) {
+	if c.isDone() {
+		return ErrClientConnClosing
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	cmps := []clientv3.Cmp{clientv3.Compare(clientv3.CreateRevision(path), ">", 0)}
+	if version >= 0 {
+		cmps = append(cmps, clientv3.Compare(clientv3.Version(path), "=", int64(version)))
+	}
+	resp, err := c.conn.Txn(ctx).If(cmps...).
+		Then(clientv3.OpPut(path, data, clientv3.WithIgnoreLease())).
+		Else(clientv3.OpGet(path)).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("update node %s fail(err:%v)", path, err)
+	}
+	if resp.Succeeded {
+		return nil
+	}
+	kvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(kvs) == 0 {
+		return fmt.Errorf("update node %s fail(node is not exists)", path)
+	}
+	return r.NewVersionConflictError(path, version, int32(kvs[0].Version))
+}
+
+//CreatePersistentNode 创建持久化的节点,节点已存在时不作修改
+func (c *client) CreatePersistentNode(path string, data string) (err error) {
+	if c.isDone() {
+		return ErrClientConnClosing
+	}
+	if path == "/" {
+		return nil
+	}
+	if err = c.createParents(path); err != nil {
+		return err
+	}
+	_, err = c.create(path, data, clientv3.NoLease)
+	if err == errNodeExists {
+		return nil
+	}
+	return err
+}
+
+//CreateTempNode 创建临时节点,节点与当前会话的租约绑定
+func (c *client) CreateTempNode(path string, data string) (err error) {
+	if c.isDone() {
+		return ErrClientConnClosing
+	}
+	if err = c.createParents(path); err != nil {
+		return err
+	}
+	lease, err := c.getLease()
+	if err != nil {
+		return err
+	}
+	if _, err = c.create(path, data, lease); err == errNodeExists {
+		return fmt.Errorf("create node : %s fail(%v)", path, err)
+	}
+	return err
+}
+
+//CreateSeqNode 创建临时顺序节点,返回包含序号的完整路径
+func (c *client) CreateSeqNode(path string, data string) (rpath string, err error) {
+	if c.isDone() {
+		return "", ErrClientConnClosing
+	}
+	if err = c.createParents(path); err != nil {
+		return "", err
+	}
+	lease, err := c.getLease()
+	if err != nil {
+		return "", err
+	}
+	for {
+		seq, err := c.nextSeq(path)
+		if err != nil {
+			return "", err
+		}
+		rpath = fmt.Sprintf("%s%0*d", path, seqLen, seq)
+		if _, err = c.create(rpath, data, lease); err != errNodeExists {
+			return rpath, err
+		}
+	}
+}
+
+//Delete 删除节点
+func (c *client) Delete(path string) error {
+	if c.isDone() {
+		return ErrClientConnClosing
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	_, err := c.conn.Delete(ctx, path)
+	return err
+}
+
+//WatchValue 监控节点值变化,值发生变化后通知一次
+func (c *client) WatchValue(path string) (data chan registry.ValueWatcher, err error) {
+	if c.isDone() {
+		return nil, ErrClientConnClosing
+	}
+	ctx, cancel := c.ctx()
+	resp, err := c.conn.Get(ctx, path, clientv3.WithCountOnly())
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if resp.Count == 0 {
+		return nil, fmt.Errorf("node(%s) is not exist", path)
+	}
+	data = make(chan registry.ValueWatcher, 1)
+	wctx, wcancel := context.WithCancel(context.Background())
+	wch := c.conn.Watch(wctx, path, clientv3.WithRev(resp.Header.Revision+1))
+	go func() {
+		defer wcancel()
+		for {
+			select {
+			case <-c.closeCh:
+				data <- &valueEntity{path: path, Err: ErrClientConnClosing}
+				return
+			case wresp, ok := <-wch:
+				if !ok || c.isDone() {
+					data <- &valueEntity{path: path, Err: ErrClientConnClosing}
+					return
+				}
+				if err := wresp.Err(); err != nil {
+					data <- &valueEntity{path: path, Err: err}
+					return
+				}
+				for _, ev := range wresp.Events {
+					if ev.Type == mvccpb.DELETE {
+						data <- &valueEntity{path: path, Err: ErrNodeDeleted}
+						return
+					}
+					data <- &valueEntity{path: path, Value: ev.Kv.Value, version: int32(ev.Kv.Version)}
+					return
+				}
+			}
+		}
+	}()
+	return data, nil
+}
+
+//WatchChildren 监控子节点变化,新增或删除子节点后通知一次
+func (c *client) WatchChildren(path string) (ch chan registry.ChildrenWatcher, err error) {
+	if c.isDone() {
+		return nil, ErrClientConnClosing
+	}
+	ctx, cancel := c.ctx()
+	resp, err := c.conn.Get(ctx, path, clientv3.WithCountOnly())
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	ch = make(chan registry.ChildrenWatcher, 1)
+	prefix := dirPrefix(path)
+	wctx, wcancel := context.WithCancel(context.Background())
+	wch := c.conn.Watch(wctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(resp.Header.Revision+1))
+	go func() {
+		defer wcancel()
+		for {
+			select {
+			case <-c.closeCh:
+				ch <- &valuesEntity{path: path, Err: ErrClientConnClosing}
+				return
+			case wresp, ok := <-wch:
+				if !ok || c.isDone() {
+					ch <- &valuesEntity{path: path, Err: ErrClientConnClosing}
+					return
+				}
+				if err := wresp.Err(); err != nil {
+					ch <- &valuesEntity{path: path, Err: err}
+					return
+				}
+				for _, ev := range wresp.Events {
+					name := strings.TrimPrefix(string(ev.Kv.Key), prefix)
+					if strings.Contains(name, "/") {
+						continue
+					}
+					if ev.Type == mvccpb.DELETE || ev.IsCreate() {
+						paths, version, err := c.GetChildren(path)
+						ch <- &valuesEntity{path: path, values: paths, version: version, Err: err}
+						return
+					}
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+//GetSeparator 获取路径分隔符
+func (c *client) GetSeparator() string {
+	return "/"
+}
+
+//CanWirteDataInDir 目录节点是否可以保存数据
+func (c *client) CanWirteDataInDir() bool {
+	return true
+}
+
+//isDone 连接是否已关闭
+func (c *client) isDone() bool {
+	return atomic.LoadInt32(&c.done) == 1
+}
+
+//Close 关闭连接并撤销租约,所有临时节点随之删除
+func (c *client) Close() error {
+	c.once.Do(func() {
+		atomic.StoreInt32(&c.done, 1)
+		close(c.closeCh)
+		c.leaseLock.Lock()
+		if c.leaseID != clientv3.NoLease {
+			ctx, cancel := c.ctx()
+			c.conn.Revoke(ctx, c.leaseID)
+			cancel()
+		}
+		c.leaseLock.Unlock()
+		c.conn.Close()
+	})
+	return nil
+}
+
+var errNodeExists = errors.New("node already exists")
+
+//create 节点不存在时创建节点
+func (c *client) create(path string, data string, lease clientv3.LeaseID) (int64, error) {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.conn.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(path), "=", 0)).
+		Then(clientv3.OpPut(path, data, clientv3.WithLease(lease))).
+		Commit()
+	if err != nil {
+		return 0, fmt.Errorf("create node : %s fail(err:%v)", path, err)
+	}
+	if !resp.Succeeded {
+		return 0, errNodeExists
+	}
+	return resp.Header.Revision, nil
+}
+
+//createParents 创建所有上级目录节点
+func (c *client) createParents(path string) error {
+	nodes := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(nodes); i++ {
+		p := "/" + strings.Join(nodes[:i], "/")
+		if _, err := c.create(p, "", clientv3.NoLease); err != nil && err != errNodeExists {
+			return err
+		}
+	}
+	return nil
+}
+
+//nextSeq 获取路径的下一个序号
+func (c *client) nextSeq(path string) (int64, error) {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.conn.Get(ctx, path, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return 0, err
+	}
+	var max int64
+	for _, kv := range resp.Kvs {
+		suffix := strings.TrimPrefix(string(kv.Key), path)
+		if len(suffix) != seqLen {
+			continue
+		}
+		if n, err := strconv.ParseInt(suffix, 10, 64); err == nil && n > max {
+			max = n
+		}
+	}
+	return max + 1, nil
+}
+
+//getLease 获取当前会话的租约，租约失效后重新申请
+func (c *client) getLease() (clientv3.LeaseID, error) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+	if c.leaseID != clientv3.NoLease {
+		return c.leaseID, nil
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.conn.Grant(ctx, TTL)
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("etcd申请租约失败:%v", err)
+	}
+	kch, err := c.conn.KeepAlive(context.Background(), resp.ID)
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("etcd租约续期失败:%v", err)
+	}
+	c.leaseID = resp.ID
+	go c.keepAlive(resp.ID, kch)
+	return c.leaseID, nil
+}
+
+func (c *client) keepAlive(id clientv3.LeaseID, kch <-chan *clientv3.LeaseKeepAliveResponse) {
+	for range kch {
+	}
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+	if c.leaseID == id {
+		c.leaseID = clientv3.NoLease
+	}
+	if !c.isDone() {
+		c.logger.Warnf("etcd租约已失效,临时节点将被删除:%x", id)
+	}
+}
+
+//dirPrefix 获取子节点的key前缀
+func dirPrefix(path string) string {
+	return strings.TrimRight(path, "/") + "/"
+}
+
+//children 获取所有直接子节点名称
+func children(path string, kvs []*mvccpb.KeyValue) []string {
+	prefix := dirPrefix(path)
+	names := make(map[string]bool)
+	paths := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		name := strings.SplitN(strings.TrimPrefix(string(kv.Key), prefix), "/", 2)[0]
+		if name == "" || names[name] {
+			continue
+		}
+		names[name] = true
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+type valueEntity struct {
+	Value   []byte
+	version int32
+	path    string
+	Err     error
+}
+type valuesEntity struct {
+	values  []string
+	version int32
+	path    string
+	Err     error
+}
+
+func (v *valueEntity) GetPath() string {
+	return v.path
+}
+func (v *valueEntity) GetValue() ([]byte, int32) {
+	return v.Value, v.version
+}
+func (v *valueEntity) GetError() error {
+	return v.Err
+}
+
+func (v *valuesEntity) GetValue() ([]string, int32) {
+	return v.values, v.version
+}
+func (v *valuesEntity) GetError() error {
+	return v.Err
+}
+func (v *valuesEntity) GetPath() string {
+	return v.path
+}
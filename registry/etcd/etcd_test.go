@@ -0,0 +1,173 @@
+package etcd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/embed"
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/library/ut"
+	r "github.com/sereiner/parrot/registry"
+)
+
+//startEmbedEtcd 启动内嵌的etcd服务器
+func startEmbedEtcd(t *testing.T) (addr string, close func()) {
+	dir, err := ioutil.TempDir("", "parrot-etcd")
+	ut.Expect(t, err, nil)
+	cfg := embed.NewConfig()
+	cfg.Dir = dir
+	clientURL, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", getFreePort(t)))
+	peerURL, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", getFreePort(t)))
+	cfg.LCUrls = []url.URL{*clientURL}
+	cfg.ACUrls = []url.URL{*clientURL}
+	cfg.LPUrls = []url.URL{*peerURL}
+	cfg.APUrls = []url.URL{*peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	e, err := embed.StartEtcd(cfg)
+	ut.Expect(t, err, nil)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(time.Second * 10):
+		e.Close()
+		t.Fatal("内嵌etcd启动超时")
+	}
+	return clientURL.Host, func() {
+		e.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func getFreePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	ut.Expect(t, err, nil)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newTestClient(t *testing.T, addr string) *client {
+	c, err := newClient([]string{addr}, "", "", logger.New("etcd"))
+	ut.Expect(t, err, nil)
+	return c
+}
+
+func TestEtcdNode(t *testing.T) {
+	addr, stop := startEmbedEtcd(t)
+	defer stop()
+	c := newTestClient(t, addr)
+	defer c.Close()
+
+	err := c.CreatePersistentNode("/parrot/sys/api/t/conf", `{"address":":8090"}`)
+	ut.Expect(t, err, nil)
+
+	b, err := c.Exists("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, b, true)
+	b, _ = c.Exists("/parrot/sys")
+	ut.Expect(t, b, true)
+	b, _ = c.Exists("/parrot/none")
+	ut.Expect(t, b, false)
+
+	data, version, err := c.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(data), `{"address":":8090"}`)
+	ut.Expect(t, version, int32(1))
+
+	//已存在的节点不会被覆盖
+	err = c.CreatePersistentNode("/parrot/sys/api/t/conf", `{}`)
+	ut.Expect(t, err, nil)
+	data, _, _ = c.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, string(data), `{"address":":8090"}`)
+
+	err = c.Update("/parrot/sys/api/t/conf", `{"address":":9090"}`, version)
+	ut.Expect(t, err, nil)
+	data, version, _ = c.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, string(data), `{"address":":9090"}`)
+	ut.Expect(t, version, int32(2))
+
+	//版本号不一致时更新失败
+	err = c.Update("/parrot/sys/api/t/conf", `{}`, 1)
+	ut.Expect(t, r.IsVersionConflict(err), true)
+	err = c.Update("/parrot/sys/api/t/none", `{}`, -1)
+	ut.Refute(t, err, nil)
+	ut.Expect(t, r.IsVersionConflict(err), false)
+
+	c.CreatePersistentNode("/parrot/sys/api/t/conf/router", `{}`)
+	c.CreatePersistentNode("/parrot/sys/api/t/conf/auth", `{}`)
+	c.CreatePersistentNode("/parrot/sys/api/t/conf/auth/jwt", `{}`)
+	paths, _, err := c.GetChildren("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, paths, []string{"auth", "router"})
+
+	ut.Expect(t, c.Delete("/parrot/sys/api/t/conf/router"), nil)
+	paths, _, _ = c.GetChildren("/parrot/sys/api/t/conf")
+	ut.Expect(t, paths, []string{"auth"})
+}
+
+func TestEtcdTempNode(t *testing.T) {
+	addr, stop := startEmbedEtcd(t)
+	defer stop()
+	c1 := newTestClient(t, addr)
+	c2 := newTestClient(t, addr)
+	defer c2.Close()
+
+	err := c1.CreateTempNode("/parrot/services/api/order/providers/192.168.0.1:8090", "{}")
+	ut.Expect(t, err, nil)
+	err = c1.CreateTempNode("/parrot/services/api/order/providers/192.168.0.1:8090", "{}")
+	ut.Refute(t, err, nil)
+
+	p1, err := c1.CreateSeqNode("/parrot/dlock/dlock_", "{}")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, p1, "/parrot/dlock/dlock_0000000001")
+	p2, err := c2.CreateSeqNode("/parrot/dlock/dlock_", "{}")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, p2, "/parrot/dlock/dlock_0000000002")
+
+	//关闭会话后临时节点被删除
+	c1.Close()
+	b, _ := c2.Exists("/parrot/services/api/order/providers/192.168.0.1:8090")
+	ut.Expect(t, b, false)
+	paths, _, _ := c2.GetChildren("/parrot/dlock")
+	ut.Expect(t, paths, []string{"dlock_0000000002"})
+}
+
+func TestEtcdWatch(t *testing.T) {
+	addr, stop := startEmbedEtcd(t)
+	defer stop()
+	c := newTestClient(t, addr)
+	defer c.Close()
+
+	_, err := c.WatchValue("/parrot/none")
+	ut.Refute(t, err, nil)
+
+	c.CreatePersistentNode("/parrot/sys/api/t/conf", `{}`)
+	vch, err := c.WatchValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	cch, err := c.WatchChildren("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+
+	c.Update("/parrot/sys/api/t/conf", `{"status":"stop"}`, -1)
+	select {
+	case v := <-vch:
+		ut.Expect(t, v.GetError(), nil)
+		data, version := v.GetValue()
+		ut.Expect(t, string(data), `{"status":"stop"}`)
+		ut.Expect(t, version, int32(2))
+	case <-time.After(time.Second * 3):
+		t.Error("未收到值变更通知")
+	}
+
+	c.CreateTempNode("/parrot/sys/api/t/conf/metric", `{}`)
+	select {
+	case v := <-cch:
+		ut.Expect(t, v.GetError(), nil)
+		paths, _ := v.GetValue()
+		ut.Expect(t, paths, []string{"metric"})
+	case <-time.After(time.Second * 3):
+		t.Error("未收到子节点变更通知")
+	}
+}
@@ -0,0 +1,24 @@
+package etcd
+
+import (
+	"fmt"
+
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/parrot/registry"
+)
+
+//etcdRegistry 基于etcd的注册中心
+type etcdRegistry struct {
+}
+
+//Resolve 根据配置生成etcd客户端
+func (e *etcdRegistry) Resolve(servers []string, u string, p string, log logger.ILogging) (registry.IRegistry, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("未指定etcd服务器地址")
+	}
+	return newClient(servers, u, p, log)
+}
+
+func init() {
+	registry.Register("etcd", &etcdRegistry{})
+}
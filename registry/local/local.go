@@ -1,17 +1,16 @@
 package local
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/sereiner/library/registry"
@@ -20,41 +19,99 @@ import (
 
 var _ r.IRegistry = &local{}
 
+//HeartbeatInterval 会话心跳间隔
+var HeartbeatInterval = time.Second * 3
+
+//SessionTTL 会话超时时长,超过此时长未心跳的会话视为已失效,其临时节点将被删除
+var SessionTTL = time.Second * 15
+
+//sessionDir 会话文件保存目录,每个会话文件记录进程编号及其创建的临时节点
+const sessionDir = ".sessions"
+
+//lockFile 多进程共享同一目录时使用的锁文件
+const lockFile = ".meta/lock"
+
+//revisionFile 节点版本号索引文件
+const revisionFile = ".meta/revision"
+
+//seqFile 顺序节点序号文件,多进程共享同一序号
+const seqFile = ".meta/seq"
+
+//seqStart 顺序节点起始序号
+const seqStart = 10000
+
 type eventWatcher struct {
 	watcher chan registry.ValueWatcher
 	event   chan fsnotify.Event
 }
 
+type childrenEventWatcher struct {
+	watcher chan registry.ChildrenWatcher
+	event   chan fsnotify.Event
+}
+
+type session struct {
+	PID   int      `json:"pid"`
+	Host  string   `json:"host"`
+	Nodes []string `json:"nodes"`
+}
+
+//revisions 节点版本号索引,Revision为全局单调递增的版本号
+type revisions struct {
+	Revision int32                   `json:"revision"`
+	Nodes    map[string]*nodeRevision `json:"nodes"`
+}
+
+//nodeRevision 节点最后一次修改时的全局版本号及文件状态,文件被外部修改时据此重新分配版本号
+type nodeRevision struct {
+	Revision int32 `json:"rev"`
+	ModTime  int64 `json:"mtime"`
+	Size     int64 `json:"size"`
+}
+
 type local struct {
 	watcher      *fsnotify.Watcher
 	watcherMaps  map[string]*eventWatcher
+	childrenMaps map[string]*childrenEventWatcher
 	watchLock    sync.Mutex
 	tempNode     []string
 	tempNodeLock sync.Mutex
-	seqNode      int32
 	closeCh      chan struct{}
 	prefix       string
+	host         string
+	sessionPath  string
+	lockPath     string
+	revPath      string
+	seqPath      string
+	fileLock     sync.RWMutex
 }
 
 func newLocal(prefix string) (*local, error) {
-	if err := checkPrivileges(); err != nil {
-		return nil, err
-	}
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
 	}
-	return &local{
-		prefix:      strings.TrimRight(prefix, "/"),
-		watcher:     w,
-		watcherMaps: make(map[string]*eventWatcher),
-		tempNode:    make([]string, 0, 2),
-		seqNode:     10000,
-		closeCh:     make(chan struct{}),
-	}, nil
+	l := &local{
+		prefix:       strings.TrimRight(prefix, "/"),
+		watcher:      w,
+		watcherMaps:  make(map[string]*eventWatcher),
+		childrenMaps: make(map[string]*childrenEventWatcher),
+		tempNode:     make([]string, 0, 2),
+		closeCh:      make(chan struct{}),
+	}
+	l.host, _ = os.Hostname()
+	l.sessionPath = filepath.Join(l.prefix+r.Join("/", sessionDir), fmt.Sprintf("%d_%d", os.Getpid(), time.Now().UnixNano()))
+	l.lockPath = l.prefix + r.Join("/", lockFile)
+	l.revPath = l.prefix + r.Join("/", revisionFile)
+	l.seqPath = l.prefix + r.Join("/", seqFile)
+	if err := os.MkdirAll(filepath.Dir(l.lockPath), 0777); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return l, nil
 }
 
-//Start 启动文件监控
+//Start 启动文件监控,会话心跳及失效临时节点清理
 func (l *local) Start() {
 	go func() {
 	LOOP:
@@ -63,22 +120,46 @@ func (l *local) Start() {
 			case <-l.closeCh:
 				break LOOP
 			case event := <-l.watcher.Events:
-				func(event fsnotify.Event) {
-					//fmt.Println("event:", event.Name, event.Op, event.String())
-					l.watchLock.Lock()
-					watcher, ok := l.watcherMaps[event.Name]
-					l.watchLock.Unlock()
-					if !ok {
-						return
-					}
-					watcher.event <- event
-					delete(l.watcherMaps, event.Name)
-				}(event)
-
+				l.dispatch(event)
 			}
 		}
 		l.watcher.Close()
 	}()
+	l.reap()
+	go func() {
+		tk := time.NewTicker(HeartbeatInterval)
+		defer tk.Stop()
+		for {
+			select {
+			case <-l.closeCh:
+				return
+			case <-tk.C:
+				l.heartbeat()
+				l.reap()
+			}
+		}
+	}()
+}
+
+//dispatch 将文件事件分发给节点值与子节点监控者
+func (l *local) dispatch(event fsnotify.Event) {
+	l.watchLock.Lock()
+	defer l.watchLock.Unlock()
+	if watcher, ok := l.watcherMaps[event.Name]; ok {
+		delete(l.watcherMaps, event.Name)
+		watcher.event <- event
+	}
+	if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+		return
+	}
+	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+	dir := filepath.Dir(event.Name)
+	if watcher, ok := l.childrenMaps[dir]; ok {
+		delete(l.childrenMaps, dir)
+		watcher.event <- event
+	}
 }
 func (l *local) formatPath(path string) string {
 	if !strings.HasPrefix(path, l.prefix) {
@@ -97,18 +178,42 @@ func (l *local) GetValue(path string) (data []byte, version int32, err error) {
 		return nil, 0, errors.New(rpath + "不存在")
 	}
 	if !fs.IsDir() {
-		data, err = ioutil.ReadFile(rpath)
-		version = int32(fs.ModTime().Unix())
+		if data, err = ioutil.ReadFile(rpath); err != nil {
+			return nil, 0, err
+		}
+		version, err = l.getRevision(path, fs)
 		return
 	}
 	return l.GetValue(r.Join(path, ".init"))
 }
 func (l *local) Update(path string, data string, version int32) (err error) {
-	if b, _ := l.Exists(path); !b {
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	rpath := l.formatPath(path)
+	fs, err := os.Stat(rpath)
+	if err != nil {
 		return errors.New(path + "不存在")
 	}
-	return ioutil.WriteFile(l.formatPath(path), []byte(data), 0666)
-
+	if version >= 0 {
+		revs, err := l.loadRevisions()
+		if err != nil {
+			return err
+		}
+		current, err := l.revision(revs, path, fs)
+		if err != nil {
+			return err
+		}
+		if current != version {
+			return r.NewVersionConflictError(path, version, current)
+		}
+	}
+	if err = ioutil.WriteFile(l.formatPath(path), []byte(data), 0666); err != nil {
+		return err
+	}
+	return l.nextRevision(path, false)
 }
 func (l *local) GetChildren(path string) (paths []string, version int32, err error) {
 	rpath := l.formatPath(path)
@@ -116,14 +221,16 @@ func (l *local) GetChildren(path string) (paths []string, version int32, err err
 	if os.IsNotExist(err) {
 		return nil, 0, errors.New(path + "不存在")
 	}
-	version = int32(fs.ModTime().Unix())
+	if version, err = l.getRevision(path, fs); err != nil {
+		return nil, 0, err
+	}
 	rf, err := ioutil.ReadDir(rpath)
 	if err != nil {
 		return nil, 0, err
 	}
 	paths = make([]string, 0, len(rf))
 	for _, f := range rf {
-		if strings.HasSuffix(f.Name(), ".swp") || strings.HasPrefix(f.Name(), "~") {
+		if strings.HasSuffix(f.Name(), ".swp") || strings.HasPrefix(f.Name(), "~") || strings.HasPrefix(f.Name(), ".") {
 			continue
 		}
 		paths = append(paths, f.Name())
@@ -145,7 +252,7 @@ func (l *local) WatchValue(path string) (data chan registry.ValueWatcher, err er
 		return v.watcher, nil
 	}
 	l.watcherMaps[absPath] = &eventWatcher{
-		event:   make(chan fsnotify.Event),
+		event:   make(chan fsnotify.Event, 1),
 		watcher: make(chan registry.ValueWatcher),
 	}
 
@@ -168,18 +275,73 @@ func (l *local) WatchValue(path string) (data chan registry.ValueWatcher, err er
 	}(rpath, l.watcherMaps[absPath])
 	return l.watcherMaps[absPath].watcher, nil
 }
+
+//WatchChildren 监控目录下子节点的创建与删除,变化后通知一次
 func (l *local) WatchChildren(path string) (data chan registry.ChildrenWatcher, err error) {
-	return nil, nil
+	rpath := l.formatPath(path)
+	fs, err := os.Stat(rpath)
+	if err != nil {
+		return nil, errors.New(path + "不存在")
+	}
+	if !fs.IsDir() {
+		return nil, errors.New(path + "不是目录")
+	}
+	absPath := filepath.Clean(rpath)
+	l.watchLock.Lock()
+	defer l.watchLock.Unlock()
+	v, ok := l.childrenMaps[absPath]
+	if ok {
+		return v.watcher, nil
+	}
+	if err := l.watcher.Add(rpath); err != nil {
+		return nil, err
+	}
+	v = &childrenEventWatcher{
+		event:   make(chan fsnotify.Event, 1),
+		watcher: make(chan registry.ChildrenWatcher, 1),
+	}
+	l.childrenMaps[absPath] = v
+	go func(v *childrenEventWatcher) {
+		select {
+		case <-l.closeCh:
+			return
+		case <-v.event:
+			paths, version, err := l.GetChildren(path)
+			v.watcher <- &valuesEntity{values: paths, version: version, path: path, Err: err}
+		}
+	}(v)
+	return v.watcher, nil
 }
 func (l *local) Delete(path string) error {
+	if err := l.remove(path); err != nil {
+		return err
+	}
+	l.removeTempNode(path)
+	return nil
+}
 
+//remove 删除节点并更新版本号
+func (l *local) remove(path string) error {
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	if b, _ := l.Exists(path); !b {
 		return nil
 	}
-	return os.Remove(l.formatPath(path))
+	if err := os.Remove(l.formatPath(path)); err != nil {
+		return err
+	}
+	return l.nextRevision(path, true)
 }
 
 func (l *local) CreatePersistentNode(path string, data string) (err error) {
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	rpath := l.formatPath(path)
 	_, err = os.Stat(rpath)
 	if err == nil || os.IsExist(err) {
@@ -200,21 +362,76 @@ func (l *local) CreatePersistentNode(path string, data string) (err error) {
 	if _, err = f.WriteString(data); err != nil {
 		return err
 	}
-	return nil
+	return l.nextRevision(path, true)
 }
 func (l *local) CreateTempNode(path string, data string) (err error) {
 	if err = l.CreatePersistentNode(path, data); err != nil {
 		return err
 	}
-	l.tempNodeLock.Lock()
-	defer l.tempNodeLock.Unlock()
-	l.tempNode = append(l.tempNode, l.formatPath(path))
-	return nil
+	return l.addTempNode(path)
 }
+
+//CreateSeqNode 创建顺序节点,序号由共享同一目录的所有进程递增分配
 func (l *local) CreateSeqNode(path string, data string) (rpath string, err error) {
-	nid := atomic.AddInt32(&l.seqNode, 1)
+	if rpath, err = l.createSeqNode(path, data); err != nil {
+		return "", err
+	}
+	return rpath, l.addTempNode(rpath)
+}
+
+func (l *local) createSeqNode(path string, data string) (rpath string, err error) {
+	unlock, err := l.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	nid, err := l.nextSeq()
+	if err != nil {
+		return "", err
+	}
 	rpath = fmt.Sprintf("%s_%d", l.formatPath(path), nid)
-	return rpath, l.CreateTempNode(rpath, data)
+	if err = os.MkdirAll(filepath.Dir(rpath), 0777); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(rpath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0777)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(data)
+	f.Close()
+	if err != nil {
+		os.Remove(rpath)
+		return "", err
+	}
+	return rpath, l.nextRevision(rpath, true)
+}
+
+//nextSeq 分配顺序节点序号,须在持有锁时调用
+func (l *local) nextSeq() (int64, error) {
+	nid := int64(seqStart)
+	buff, err := ioutil.ReadFile(l.seqPath)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if len(buff) > 0 {
+		if nid, err = strconv.ParseInt(strings.TrimSpace(string(buff)), 10, 64); err != nil {
+			return 0, fmt.Errorf("序号文件%s格式错误:%v", l.seqPath, err)
+		}
+	}
+	nid++
+	tmp := l.seqPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.FormatInt(nid, 10)), 0666); err != nil {
+		return 0, err
+	}
+	return nid, os.Rename(tmp, l.seqPath)
+}
+
+//addTempNode 记录当前会话创建的临时节点
+func (l *local) addTempNode(path string) error {
+	l.tempNodeLock.Lock()
+	defer l.tempNodeLock.Unlock()
+	l.tempNode = append(l.tempNode, l.nodePath(path))
+	return l.saveSession()
 }
 func (l *local) GetSeparator() string {
 	return string(filepath.Separator)
@@ -227,12 +444,117 @@ func (l *local) Close() error {
 	l.tempNodeLock.Lock()
 	defer l.tempNodeLock.Unlock()
 	close(l.closeCh)
+	//其它存活会话已重新创建的节点(如平滑重启后的新进程)不删除
+	live, _ := l.loadSessions()
 	for _, p := range l.tempNode {
-		os.Remove(p)
+		if !live[p] {
+			l.remove(p)
+		}
 	}
+	l.tempNode = l.tempNode[:0]
+	os.Remove(l.sessionPath)
 	return nil
 }
 
+//nodePath 获取不含前缀的节点路径
+func (l *local) nodePath(path string) string {
+	return r.Join("/", strings.TrimPrefix(l.formatPath(path), l.prefix))
+}
+
+func (l *local) removeTempNode(path string) {
+	l.tempNodeLock.Lock()
+	defer l.tempNodeLock.Unlock()
+	npath := l.nodePath(path)
+	for i, p := range l.tempNode {
+		if p == npath {
+			l.tempNode = append(l.tempNode[:i], l.tempNode[i+1:]...)
+			l.saveSession()
+			return
+		}
+	}
+}
+
+//saveSession 保存当前会话的进程编号与临时节点列表
+func (l *local) saveSession() error {
+	buff, err := json.Marshal(&session{PID: os.Getpid(), Host: l.host, Nodes: l.tempNode})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.sessionPath), 0777); err != nil {
+		return err
+	}
+	tmp := l.sessionPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, buff, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.sessionPath)
+}
+
+//heartbeat 更新会话文件修改时间
+func (l *local) heartbeat() {
+	l.tempNodeLock.Lock()
+	defer l.tempNodeLock.Unlock()
+	if len(l.tempNode) == 0 {
+		return
+	}
+	now := time.Now()
+	if err := os.Chtimes(l.sessionPath, now, now); os.IsNotExist(err) {
+		l.saveSession()
+	}
+}
+
+//reap 删除心跳超时的会话创建的临时节点,同一主机上进程已退出的会话立即删除;
+//不同主机(如共享目录的多个容器)无法检测进程,仅依据心跳判断;已由存活会话重新创建的节点不删除
+func (l *local) reap() {
+	live, stale := l.loadSessions()
+	l.tempNodeLock.Lock()
+	for _, p := range l.tempNode {
+		live[p] = true
+	}
+	l.tempNodeLock.Unlock()
+	for spath, s := range stale {
+		for _, p := range s.Nodes {
+			if !live[p] {
+				l.remove(p)
+			}
+		}
+		os.Remove(spath)
+	}
+}
+
+//loadSessions 读取其它会话,返回存活会话创建的临时节点与已超时的会话
+func (l *local) loadSessions() (map[string]bool, map[string]*session) {
+	live := make(map[string]bool)
+	stale := make(map[string]*session)
+	dir := filepath.Dir(l.sessionPath)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return live, stale
+	}
+	for _, f := range files {
+		spath := filepath.Join(dir, f.Name())
+		if spath == l.sessionPath || strings.HasSuffix(f.Name(), ".tmp") {
+			continue
+		}
+		buff, err := ioutil.ReadFile(spath)
+		if err != nil {
+			continue
+		}
+		s := &session{}
+		if err := json.Unmarshal(buff, s); err != nil {
+			continue
+		}
+		if time.Since(f.ModTime()) < SessionTTL && (s.Host != l.host || processExists(s.PID)) {
+			for _, p := range s.Nodes {
+				live[p] = true
+			}
+			continue
+		}
+		stale[spath] = s
+	}
+	return live, stale
+}
+
 type valueEntity struct {
 	Value   []byte
 	version int32
@@ -266,20 +588,136 @@ func (v *valuesEntity) GetPath() string {
 	return v.path
 }
 
-func checkPrivileges() error {
-	if output, err := exec.Command("id", "-g").Output(); err == nil {
-		if gid, parseErr := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 32); parseErr == nil {
-			if gid == 0 {
-				return nil
-			}
-			return ErrRootPrivileges
-		}
+//lock 获取进程内及跨进程的排它锁
+func (l *local) lock() (unlock func(), err error) {
+	l.fileLock.Lock()
+	f, err := os.OpenFile(l.lockPath, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		l.fileLock.Unlock()
+		return nil, err
 	}
-	if runtime.GOOS == "windows" {
-		return nil
+	if err = lockFileEx(f); err != nil {
+		f.Close()
+		l.fileLock.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlockFileEx(f)
+		f.Close()
+		l.fileLock.Unlock()
+	}, nil
+}
+
+//rlock 获取进程内及跨进程的共享锁,仅用于读取
+func (l *local) rlock() (unlock func(), err error) {
+	l.fileLock.RLock()
+	f, err := os.OpenFile(l.lockPath, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		l.fileLock.RUnlock()
+		return nil, err
+	}
+	if err = lockFileSh(f); err != nil {
+		f.Close()
+		l.fileLock.RUnlock()
+		return nil, err
+	}
+	return func() {
+		unlockFileEx(f)
+		f.Close()
+		l.fileLock.RUnlock()
+	}, nil
+}
+
+func (l *local) loadRevisions() (*revisions, error) {
+	revs := &revisions{Nodes: make(map[string]*nodeRevision)}
+	buff, err := ioutil.ReadFile(l.revPath)
+	if os.IsNotExist(err) {
+		return revs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(buff) == 0 {
+		return revs, nil
+	}
+	if err = json.Unmarshal(buff, revs); err != nil {
+		return nil, fmt.Errorf("版本号索引文件%s格式错误:%v", l.revPath, err)
+	}
+	if revs.Nodes == nil {
+		revs.Nodes = make(map[string]*nodeRevision)
+	}
+	return revs, nil
+}
+
+func (l *local) saveRevisions(revs *revisions) error {
+	buff, err := json.Marshal(revs)
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("%v %s", ErrUnsupportedSystem, runtime.GOOS)
+	tmp := l.revPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, buff, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.revPath)
 }
 
-var ErrUnsupportedSystem = errors.New("Unsupported system")
-var ErrRootPrivileges = errors.New("You must have root user privileges. Possibly using 'sudo' command should help")
+//getRevision 获取节点版本号,节点未登记或已被外部修改时分配新的版本号
+func (l *local) getRevision(path string, fs os.FileInfo) (int32, error) {
+	runlock, err := l.rlock()
+	if err != nil {
+		return 0, err
+	}
+	revs, err := l.loadRevisions()
+	runlock()
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := revs.Nodes[l.nodePath(path)]; ok && n.ModTime == fs.ModTime().UnixNano() && n.Size == fs.Size() {
+		return n.Revision, nil
+	}
+
+	//需要分配新的版本号时获取排它锁,重新读取索引避免覆盖其它进程的修改
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if revs, err = l.loadRevisions(); err != nil {
+		return 0, err
+	}
+	return l.revision(revs, path, fs)
+}
+
+//revision 获取节点版本号,须在持有锁时调用
+func (l *local) revision(revs *revisions, path string, fs os.FileInfo) (int32, error) {
+	npath := l.nodePath(path)
+	if n, ok := revs.Nodes[npath]; ok && n.ModTime == fs.ModTime().UnixNano() && n.Size == fs.Size() {
+		return n.Revision, nil
+	}
+	revs.Revision++
+	revs.Nodes[npath] = &nodeRevision{Revision: revs.Revision, ModTime: fs.ModTime().UnixNano(), Size: fs.Size()}
+	return revs.Revision, l.saveRevisions(revs)
+}
+
+//nextRevision 节点修改后分配新的版本号,创建或删除节点时同时更新父目录版本号,须在持有锁时调用
+func (l *local) nextRevision(path string, parent bool) error {
+	revs, err := l.loadRevisions()
+	if err != nil {
+		return err
+	}
+	revs.Revision++
+	npath := l.nodePath(path)
+	paths := []string{npath}
+	if parent {
+		paths = append(paths, r.Join("/", filepath.ToSlash(filepath.Dir(npath))))
+	}
+	for _, p := range paths {
+		fs, err := os.Stat(l.formatPath(p))
+		if err != nil {
+			delete(revs.Nodes, p)
+			continue
+		}
+		revs.Nodes[p] = &nodeRevision{Revision: revs.Revision, ModTime: fs.ModTime().UnixNano(), Size: fs.Size()}
+	}
+	return l.saveRevisions(revs)
+}
@@ -0,0 +1,195 @@
+package local
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sereiner/library/ut"
+	r "github.com/sereiner/parrot/registry"
+)
+
+func newTestLocal(t *testing.T) (*local, string) {
+	dir, err := ioutil.TempDir("", "parrot-fs")
+	ut.Expect(t, err, nil)
+	l, err := newLocal(dir)
+	ut.Expect(t, err, nil)
+	l.Start()
+	return l, dir
+}
+
+func TestLocalWatchChildren(t *testing.T) {
+	l, dir := newTestLocal(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	err := l.CreatePersistentNode("/parrot/dlock/x", "{}")
+	ut.Expect(t, err, nil)
+	_, err = l.WatchChildren("/parrot/none")
+	ut.Refute(t, err, nil)
+
+	ch, err := l.WatchChildren("/parrot/dlock")
+	ut.Expect(t, err, nil)
+	err = l.CreateTempNode("/parrot/dlock/y", "{}")
+	ut.Expect(t, err, nil)
+	select {
+	case v := <-ch:
+		ut.Expect(t, v.GetError(), nil)
+		paths, _ := v.GetValue()
+		ut.Expect(t, paths, []string{"x", "y"})
+	case <-time.After(time.Second * 3):
+		t.Error("未收到子节点变更通知")
+	}
+
+	ch, _ = l.WatchChildren("/parrot/dlock")
+	l.Delete("/parrot/dlock/x")
+	select {
+	case v := <-ch:
+		paths, _ := v.GetValue()
+		ut.Expect(t, paths, []string{"y"})
+	case <-time.After(time.Second * 3):
+		t.Error("未收到子节点变更通知")
+	}
+}
+
+func TestLocalReap(t *testing.T) {
+	l, dir := newTestLocal(t)
+	defer os.RemoveAll(dir)
+	l.CreatePersistentNode("/parrot/services/api/order/providers/192.168.0.1:8090", "{}")
+	l.CreatePersistentNode("/parrot/services/api/order/providers/192.168.0.2:8090", "{}")
+	l.Close()
+
+	//模拟已退出进程遗留的会话文件
+	sdir := filepath.Join(dir, sessionDir)
+	os.MkdirAll(sdir, 0777)
+	host, _ := os.Hostname()
+	buff, _ := json.Marshal(&session{PID: -1, Host: host, Nodes: []string{"/parrot/services/api/order/providers/192.168.0.1:8090"}})
+	ioutil.WriteFile(filepath.Join(sdir, "1_1"), buff, 0666)
+
+	l2, _ := newLocal(dir)
+	l2.Start()
+	defer l2.Close()
+	paths, _, err := l2.GetChildren("/parrot/services/api/order/providers")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, paths, []string{"192.168.0.2:8090"})
+
+	//当前会话的临时节点在会话存活期间保留,关闭后删除
+	err = l2.CreateTempNode("/parrot/services/api/order/providers/192.168.0.3:8090", "{}")
+	ut.Expect(t, err, nil)
+	l2.reap()
+	b, _ := l2.Exists("/parrot/services/api/order/providers/192.168.0.3:8090")
+	ut.Expect(t, b, true)
+}
+
+func TestLocalReapOtherHost(t *testing.T) {
+	l, dir := newTestLocal(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+	l.CreatePersistentNode("/parrot/dlock/a", "{}")
+	l.CreatePersistentNode("/parrot/dlock/b", "{}")
+
+	//其它主机的会话无法检测进程,心跳未超时时保留
+	sdir := filepath.Join(dir, sessionDir)
+	os.MkdirAll(sdir, 0777)
+	buff, _ := json.Marshal(&session{PID: -1, Host: "other", Nodes: []string{"/parrot/dlock/a"}})
+	ioutil.WriteFile(filepath.Join(sdir, "1_1"), buff, 0666)
+	buff, _ = json.Marshal(&session{PID: -1, Host: "other", Nodes: []string{"/parrot/dlock/b"}})
+	ioutil.WriteFile(filepath.Join(sdir, "2_2"), buff, 0666)
+	stale := time.Now().Add(-SessionTTL * 2)
+	os.Chtimes(filepath.Join(sdir, "2_2"), stale, stale)
+
+	_, c1, _ := l.GetChildren("/parrot/dlock")
+	l.reap()
+	paths, c2, err := l.GetChildren("/parrot/dlock")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, paths, []string{"a"})
+	ut.Expect(t, c2 > c1, true)
+}
+
+func TestLocalReapLive(t *testing.T) {
+	l1, dir := newTestLocal(t)
+	defer os.RemoveAll(dir)
+	defer l1.Close()
+	l2, err := newLocal(dir)
+	ut.Expect(t, err, nil)
+	l2.Start()
+
+	//已退出进程的会话与存活会话包含相同节点时保留节点
+	path := "/parrot/servers/api/t/192.168.0.1:8090"
+	ut.Expect(t, l2.CreateTempNode(path, "{}"), nil)
+	sdir := filepath.Join(dir, sessionDir)
+	host, _ := os.Hostname()
+	buff, _ := json.Marshal(&session{PID: -1, Host: host, Nodes: []string{path}})
+	ioutil.WriteFile(filepath.Join(sdir, "1_1"), buff, 0666)
+	l1.reap()
+	b, _ := l1.Exists(path)
+	ut.Expect(t, b, true)
+	_, err = os.Stat(filepath.Join(sdir, "1_1"))
+	ut.Expect(t, os.IsNotExist(err), true)
+
+	//当前会话重新创建的节点,原会话关闭时不删除
+	ut.Expect(t, l1.Delete(path), nil)
+	ut.Expect(t, l1.CreateTempNode(path, "{}"), nil)
+	l2.Close()
+	b, _ = l1.Exists(path)
+	ut.Expect(t, b, true)
+}
+
+func TestLocalSeqNode(t *testing.T) {
+	l1, dir := newTestLocal(t)
+	defer os.RemoveAll(dir)
+	l2, err := newLocal(dir)
+	ut.Expect(t, err, nil)
+	l2.Start()
+	defer l2.Close()
+
+	//共享同一目录的会话分配不同的序号
+	p1, err := l1.CreateSeqNode("/parrot/lock/dlock_", "{}")
+	ut.Expect(t, err, nil)
+	p2, err := l2.CreateSeqNode("/parrot/lock/dlock_", "{}")
+	ut.Expect(t, err, nil)
+	ut.Refute(t, p1, p2)
+
+	l1.Close()
+	b, _ := l2.Exists(p2)
+	ut.Expect(t, b, true)
+	b, _ = l2.Exists(p1)
+	ut.Expect(t, b, false)
+}
+
+func TestLocalRevision(t *testing.T) {
+	l, dir := newTestLocal(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	l.CreatePersistentNode("/parrot/sys/api/t/conf", "{}")
+	_, v1, err := l.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	_, c1, _ := l.GetChildren("/parrot/sys/api/t")
+
+	l.Update("/parrot/sys/api/t/conf", `{"status":"stop"}`, -1)
+	_, v2, _ := l.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, v2 > v1, true)
+	_, c2, _ := l.GetChildren("/parrot/sys/api/t")
+	ut.Expect(t, c2, c1)
+
+	//外部修改文件后版本号递增
+	ioutil.WriteFile(filepath.Join(dir, "parrot/sys/api/t/conf"), []byte(`{"status":"start"}`), 0666)
+	_, v3, _ := l.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, v3 > v2, true)
+
+	//版本号不一致时更新失败
+	err = l.Update("/parrot/sys/api/t/conf", `{}`, v2)
+	ut.Expect(t, r.IsVersionConflict(err), true)
+	err = l.Update("/parrot/sys/api/t/conf", `{"status":"stop"}`, v3)
+	ut.Expect(t, err, nil)
+
+	l.CreatePersistentNode("/parrot/sys/api/t/router", "{}")
+	_, c3, _ := l.GetChildren("/parrot/sys/api/t")
+	ut.Expect(t, c3 > c2, true)
+	paths, _, _ := l.GetChildren("/")
+	ut.Expect(t, paths, []string{"parrot"})
+}
@@ -0,0 +1,20 @@
+// +build !windows
+
+package local
+
+import (
+	"os"
+	"syscall"
+)
+
+func lockFileEx(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFileEx(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+func lockFileSh(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_SH)
+}
@@ -0,0 +1,44 @@
+package local
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+//lockfileExclusiveLock LockFileEx排它锁标识
+const lockfileExclusiveLock = 0x00000002
+
+var (
+	modkernel32      = windows.NewLazySystemDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+//lockFileRange 锁定文件首字节,其它进程锁定时阻塞等待
+func lockFileRange(f *os.File, flags uint32) error {
+	ol := new(windows.Overlapped)
+	r, _, err := procLockFileEx.Call(f.Fd(), uintptr(flags), 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func lockFileEx(f *os.File) error {
+	return lockFileRange(f, lockfileExclusiveLock)
+}
+
+func unlockFileEx(f *os.File) error {
+	ol := new(windows.Overlapped)
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func lockFileSh(f *os.File) error {
+	return lockFileRange(f, 0)
+}
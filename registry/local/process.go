@@ -0,0 +1,21 @@
+// +build !windows
+
+package local
+
+import (
+	"os"
+	"syscall"
+)
+
+//processExists 检查进程是否存在
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
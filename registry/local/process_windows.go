@@ -0,0 +1,6 @@
+package local
+
+//processExists windows下无法可靠检测进程,仅依赖心跳判断会话是否失效
+func processExists(pid int) bool {
+	return pid > 0
+}
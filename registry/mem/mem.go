@@ -0,0 +1,432 @@
+package mem
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sereiner/library/registry"
+	r "github.com/sereiner/parrot/registry"
+)
+
+var _ r.IRegistry = &client{}
+
+var (
+	//ErrClientConnClosing 会话已关闭
+	ErrClientConnClosing = errors.New("mem: the client session is closing")
+	//ErrNodeDeleted 监控的节点已删除
+	ErrNodeDeleted = errors.New("mem: node has been deleted")
+)
+
+// stores 所有内存存储,相同名称的注册中心共享同一份数据
+var stores = make(map[string]*store)
+var storeLock sync.Mutex
+
+// getStore 获取或创建指定名称的内存存储
+func getStore(name string) *store {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	if s, ok := stores[name]; ok {
+		return s
+	}
+	s := newStore()
+	stores[name] = s
+	return s
+}
+
+type node struct {
+	data     []byte
+	version  int32
+	cversion int32
+	seq      int32
+	owner    *client
+	children map[string]bool
+}
+
+type valueWatcher struct {
+	owner *client
+	ch    chan registry.ValueWatcher
+}
+type childrenWatcher struct {
+	owner *client
+	ch    chan registry.ChildrenWatcher
+}
+
+// store 内存节点树,所有会话共享
+type store struct {
+	mu               sync.Mutex
+	nodes            map[string]*node
+	valueWatchers    map[string][]*valueWatcher
+	childrenWatchers map[string][]*childrenWatcher
+	events           []func()
+}
+
+func newStore() *store {
+	return &store{
+		nodes:            map[string]*node{"/": {children: make(map[string]bool)}},
+		valueWatchers:    make(map[string][]*valueWatcher),
+		childrenWatchers: make(map[string][]*childrenWatcher),
+	}
+}
+
+// client 内存注册中心的一个会话,会话关闭时删除其创建的临时节点
+type client struct {
+	store *store
+	done  bool
+}
+
+// New 在指定名称的内存存储上创建新会话
+func New(name string) r.IRegistry {
+	return newClient(name)
+}
+
+func newClient(name string) *client {
+	return &client{store: getStore(name)}
+}
+
+// Exists 检查节点是否存在
+func (c *client) Exists(path string) (bool, error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	_, ok := s.nodes[formatPath(path)]
+	return ok, nil
+}
+
+// GetValue 获取节点的值
+func (c *client) GetValue(path string) (data []byte, version int32, err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	n, ok := s.nodes[formatPath(path)]
+	if !ok {
+		return nil, 0, fmt.Errorf("node(%s) is not exist", path)
+	}
+	return copyBytes(n.data), n.version, nil
+}
+
+// GetChildren 获取子节点,版本号为子节点变更次数
+func (c *client) GetChildren(path string) (paths []string, version int32, err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	n, ok := s.nodes[formatPath(path)]
+	if !ok {
+		return nil, 0, fmt.Errorf("node(%s) is not exist", path)
+	}
+	return n.childNames(), n.cversion, nil
+}
+
+// Update 更新节点的值,version小于0时不检查版本号
+func (c *client) Update(path string, data string, version int32) (err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return ErrClientConnClosing
+	}
+	rpath := formatPath(path)
+	n, ok := s.nodes[rpath]
+	if !ok {
+		return fmt.Errorf("update node %s fail(node is not exists)", path)
+	}
+	if version >= 0 && version != n.version {
+		return r.NewVersionConflictError(path, version, n.version)
+	}
+	n.data = []byte(data)
+	n.version++
+	s.notifyValue(rpath, n)
+	return nil
+}
+
+// CreatePersistentNode 创建持久化的节点,节点已存在时不作修改
+func (c *client) CreatePersistentNode(path string, data string) (err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return ErrClientConnClosing
+	}
+	rpath := formatPath(path)
+	if _, ok := s.nodes[rpath]; ok {
+		return nil
+	}
+	s.createParents(rpath)
+	s.create(rpath, data, nil)
+	return nil
+}
+
+// CreateTempNode 创建临时节点,会话关闭时自动删除
+func (c *client) CreateTempNode(path string, data string) (err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return ErrClientConnClosing
+	}
+	rpath := formatPath(path)
+	if _, ok := s.nodes[rpath]; ok {
+		return fmt.Errorf("create node : %s fail(node already exists)", path)
+	}
+	s.createParents(rpath)
+	s.create(rpath, data, c)
+	return nil
+}
+
+// CreateSeqNode 创建临时顺序节点,序号由父节点维护
+func (c *client) CreateSeqNode(path string, data string) (rpath string, err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return "", ErrClientConnClosing
+	}
+	npath := formatPath(path)
+	s.createParents(npath)
+	parent := s.nodes[parentPath(npath)]
+	for {
+		parent.seq++
+		rpath = fmt.Sprintf("%s%010d", npath, parent.seq)
+		if _, ok := s.nodes[rpath]; !ok {
+			break
+		}
+	}
+	s.create(rpath, data, c)
+	return rpath, nil
+}
+
+// Delete 删除节点,包含子节点时删除失败
+func (c *client) Delete(path string) error {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return ErrClientConnClosing
+	}
+	rpath := formatPath(path)
+	n, ok := s.nodes[rpath]
+	if !ok {
+		return nil
+	}
+	if len(n.children) > 0 {
+		return fmt.Errorf("delete node : %s fail(node has children)", path)
+	}
+	s.delete(rpath)
+	return nil
+}
+
+// WatchValue 监控节点值变化,值发生变化后通知一次
+func (c *client) WatchValue(path string) (data chan registry.ValueWatcher, err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return nil, ErrClientConnClosing
+	}
+	rpath := formatPath(path)
+	if _, ok := s.nodes[rpath]; !ok {
+		return nil, fmt.Errorf("node(%s) is not exist", path)
+	}
+	w := &valueWatcher{owner: c, ch: make(chan registry.ValueWatcher, 1)}
+	s.valueWatchers[rpath] = append(s.valueWatchers[rpath], w)
+	return w.ch, nil
+}
+
+// WatchChildren 监控子节点变化,新增或删除子节点后通知一次
+func (c *client) WatchChildren(path string) (ch chan registry.ChildrenWatcher, err error) {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return nil, ErrClientConnClosing
+	}
+	rpath := formatPath(path)
+	if _, ok := s.nodes[rpath]; !ok {
+		return nil, fmt.Errorf("node(%s) is not exist", path)
+	}
+	w := &childrenWatcher{owner: c, ch: make(chan registry.ChildrenWatcher, 1)}
+	s.childrenWatchers[rpath] = append(s.childrenWatchers[rpath], w)
+	return w.ch, nil
+}
+
+// GetSeparator 获取路径分隔符
+func (c *client) GetSeparator() string {
+	return "/"
+}
+
+// CanWirteDataInDir 目录节点是否可以保存数据
+func (c *client) CanWirteDataInDir() bool {
+	return true
+}
+
+// Close 关闭会话,删除当前会话创建的临时节点并结束所有监控
+func (c *client) Close() error {
+	s := c.store
+	s.mu.Lock()
+	defer s.unlock()
+	if c.done {
+		return nil
+	}
+	c.done = true
+	paths := make([]string, 0, 2)
+	for p, n := range s.nodes {
+		if n.owner == c {
+			paths = append(paths, p)
+		}
+	}
+	//先删除深层节点
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+	for _, p := range paths {
+		s.delete(p)
+	}
+	for p, ws := range s.valueWatchers {
+		s.valueWatchers[p] = ws[:0]
+		for _, w := range ws {
+			if w.owner == c {
+				s.send(w.ch, &valueEntity{path: p, Err: ErrClientConnClosing})
+				continue
+			}
+			s.valueWatchers[p] = append(s.valueWatchers[p], w)
+		}
+	}
+	for p, ws := range s.childrenWatchers {
+		s.childrenWatchers[p] = ws[:0]
+		for _, w := range ws {
+			if w.owner == c {
+				s.sendChildren(w.ch, &valuesEntity{path: p, Err: ErrClientConnClosing})
+				continue
+			}
+			s.childrenWatchers[p] = append(s.childrenWatchers[p], w)
+		}
+	}
+	return nil
+}
+
+// unlock 释放锁后发送监控通知,未读取通知的监控者不阻塞其它操作
+func (s *store) unlock() {
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+	for _, e := range events {
+		e()
+	}
+}
+
+// send 记录节点值通知,须在持有锁时调用
+func (s *store) send(ch chan registry.ValueWatcher, v *valueEntity) {
+	s.events = append(s.events, func() { ch <- v })
+}
+
+// sendChildren 记录子节点通知,须在持有锁时调用
+func (s *store) sendChildren(ch chan registry.ChildrenWatcher, v *valuesEntity) {
+	s.events = append(s.events, func() { ch <- v })
+}
+
+func (s *store) create(path string, data string, owner *client) {
+	s.nodes[path] = &node{data: []byte(data), owner: owner, children: make(map[string]bool)}
+	ppath := parentPath(path)
+	parent := s.nodes[ppath]
+	parent.children[baseName(path)] = true
+	parent.cversion++
+	s.notifyChildren(ppath, parent)
+}
+
+func (s *store) createParents(path string) {
+	nodes := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(nodes); i++ {
+		p := "/" + strings.Join(nodes[:i], "/")
+		if _, ok := s.nodes[p]; !ok {
+			s.create(p, "", nil)
+		}
+	}
+}
+
+func (s *store) delete(path string) {
+	delete(s.nodes, path)
+	for _, w := range s.valueWatchers[path] {
+		s.send(w.ch, &valueEntity{path: path, Err: ErrNodeDeleted})
+	}
+	delete(s.valueWatchers, path)
+	ppath := parentPath(path)
+	if parent, ok := s.nodes[ppath]; ok {
+		delete(parent.children, baseName(path))
+		parent.cversion++
+		s.notifyChildren(ppath, parent)
+	}
+}
+
+func (s *store) notifyValue(path string, n *node) {
+	for _, w := range s.valueWatchers[path] {
+		s.send(w.ch, &valueEntity{path: path, Value: copyBytes(n.data), version: n.version})
+	}
+	delete(s.valueWatchers, path)
+}
+
+func (s *store) notifyChildren(path string, n *node) {
+	for _, w := range s.childrenWatchers[path] {
+		s.sendChildren(w.ch, &valuesEntity{path: path, values: n.childNames(), version: n.cversion})
+	}
+	delete(s.childrenWatchers, path)
+}
+
+func (n *node) childNames() []string {
+	paths := make([]string, 0, len(n.children))
+	for k := range n.children {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func formatPath(path string) string {
+	return r.Join("/", path)
+}
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		return path[:i]
+	}
+	return "/"
+}
+func baseName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+func copyBytes(b []byte) []byte {
+	n := make([]byte, len(b))
+	copy(n, b)
+	return n
+}
+
+type valueEntity struct {
+	Value   []byte
+	version int32
+	path    string
+	Err     error
+}
+type valuesEntity struct {
+	values  []string
+	version int32
+	path    string
+	Err     error
+}
+
+func (v *valueEntity) GetPath() string {
+	return v.path
+}
+func (v *valueEntity) GetValue() ([]byte, int32) {
+	return v.Value, v.version
+}
+func (v *valueEntity) GetError() error {
+	return v.Err
+}
+
+func (v *valuesEntity) GetValue() ([]string, int32) {
+	return v.values, v.version
+}
+func (v *valuesEntity) GetError() error {
+	return v.Err
+}
+func (v *valuesEntity) GetPath() string {
+	return v.path
+}
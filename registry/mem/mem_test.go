@@ -0,0 +1,91 @@
+package mem
+
+import (
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	r "github.com/sereiner/parrot/registry"
+)
+
+func TestMemNode(t *testing.T) {
+	c := newClient("TestMemNode")
+	defer c.Close()
+
+	err := c.CreatePersistentNode("/parrot/sys/api/t/conf", `{"address":":8090"}`)
+	ut.Expect(t, err, nil)
+	b, _ := c.Exists("/parrot/sys")
+	ut.Expect(t, b, true)
+	b, _ = c.Exists("/parrot/none")
+	ut.Expect(t, b, false)
+
+	data, version, err := c.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, string(data), `{"address":":8090"}`)
+	ut.Expect(t, version, int32(0))
+
+	err = c.Update("/parrot/sys/api/t/conf", `{"address":":9090"}`, version)
+	ut.Expect(t, err, nil)
+	err = c.Update("/parrot/sys/api/t/conf", `{}`, version)
+	ut.Expect(t, r.IsVersionConflict(err), true)
+	data, version, _ = c.GetValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, string(data), `{"address":":9090"}`)
+	ut.Expect(t, version, int32(1))
+
+	c.CreatePersistentNode("/parrot/sys/api/t/conf/router", `{}`)
+	c.CreatePersistentNode("/parrot/sys/api/t/conf/auth", `{}`)
+	paths, _, err := c.GetChildren("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, paths, []string{"auth", "router"})
+
+	ut.Refute(t, c.Delete("/parrot/sys/api/t"), nil)
+	ut.Expect(t, c.Delete("/parrot/sys/api/t/conf/router"), nil)
+	paths, _, _ = c.GetChildren("/parrot/sys/api/t/conf")
+	ut.Expect(t, paths, []string{"auth"})
+}
+
+func TestMemSession(t *testing.T) {
+	c1 := newClient("TestMemSession")
+	c2 := newClient("TestMemSession")
+	defer c2.Close()
+
+	err := c1.CreateTempNode("/parrot/services/api/order/providers/192.168.0.1:8090", "{}")
+	ut.Expect(t, err, nil)
+	err = c2.CreateTempNode("/parrot/services/api/order/providers/192.168.0.1:8090", "{}")
+	ut.Refute(t, err, nil)
+
+	p1, _ := c1.CreateSeqNode("/parrot/dlock/dlock_", "{}")
+	ut.Expect(t, p1, "/parrot/dlock/dlock_0000000001")
+	p2, _ := c2.CreateSeqNode("/parrot/dlock/dlock_", "{}")
+	ut.Expect(t, p2, "/parrot/dlock/dlock_0000000002")
+
+	ch, err := c2.WatchChildren("/parrot/dlock")
+	ut.Expect(t, err, nil)
+	c1.Close()
+
+	v := <-ch
+	ut.Expect(t, v.GetError(), nil)
+	paths, _ := v.GetValue()
+	ut.Expect(t, paths, []string{"dlock_0000000002"})
+	b, _ := c2.Exists("/parrot/services/api/order/providers/192.168.0.1:8090")
+	ut.Expect(t, b, false)
+}
+
+func TestMemWatchValue(t *testing.T) {
+	c := newClient("TestMemWatchValue")
+	_, err := c.WatchValue("/parrot/none")
+	ut.Refute(t, err, nil)
+
+	c.CreatePersistentNode("/parrot/sys/api/t/conf", `{}`)
+	ch, err := c.WatchValue("/parrot/sys/api/t/conf")
+	ut.Expect(t, err, nil)
+	c.Update("/parrot/sys/api/t/conf", `{"status":"stop"}`, -1)
+	v := <-ch
+	data, version := v.GetValue()
+	ut.Expect(t, string(data), `{"status":"stop"}`)
+	ut.Expect(t, version, int32(1))
+
+	ch, _ = c.WatchValue("/parrot/sys/api/t/conf")
+	c.Close()
+	v = <-ch
+	ut.Expect(t, v.GetError(), ErrClientConnClosing)
+}
@@ -0,0 +1,23 @@
+package mem
+
+import (
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/parrot/registry"
+)
+
+// memRegistry 基于内存的注册中心,用于测试和单进程运行
+type memRegistry struct {
+}
+
+// Resolve 根据存储名称创建内存注册中心会话
+func (m *memRegistry) Resolve(servers []string, u string, p string, log logger.ILogging) (registry.IRegistry, error) {
+	name := "default"
+	if len(servers) > 0 && servers[0] != "" {
+		name = servers[0]
+	}
+	return newClient(name), nil
+}
+
+func init() {
+	registry.Register("mem", &memRegistry{})
+}
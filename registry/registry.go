@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 
@@ -28,6 +29,35 @@ type IRegistry interface {
 	Close() error
 }
 
+//ErrVersionConflict 更新节点时版本号与当前版本号不一致
+var ErrVersionConflict = errors.New("registry: version conflict")
+
+//VersionConflictError 节点版本号冲突错误,可通过errors.Is(err, ErrVersionConflict)判断
+type VersionConflictError struct {
+	Path    string
+	Version int32
+	Current int32
+}
+
+//NewVersionConflictError 构建版本号冲突错误
+func NewVersionConflictError(path string, version int32, current int32) *VersionConflictError {
+	return &VersionConflictError{Path: path, Version: version, Current: current}
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("update node %s fail(version:%d,current:%d): %v", e.Path, e.Version, e.Current, ErrVersionConflict)
+}
+
+//Is 是否为版本号冲突错误
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+//IsVersionConflict 检查错误是否为版本号冲突
+func IsVersionConflict(err error) bool {
+	return errors.Is(err, ErrVersionConflict)
+}
+
 //GetRegistry 获取注册中心
 var registryMap cmap.ConcurrentMap
 
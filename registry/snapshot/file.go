@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//manifestName 快照描述文件,以.开头,导出目录可直接作为本地文件系统注册中心使用
+const manifestName = ".snapshot.json"
+
+//IsArchive 检查路径是否为压缩包(.tar.gz,.tgz)
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+//Save 保存快照到目录或压缩包,节点按本地文件系统注册中心的目录结构存放,包含子节点的节点数据保存在.init文件中
+func (s *Snapshot) Save(path string) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	if IsArchive(path) {
+		return saveArchive(path, files)
+	}
+	for name, data := range files {
+		fpath := filepath.Join(path, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(fpath, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Load 从目录或压缩包加载快照
+func Load(path string) (*Snapshot, error) {
+	read := func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(path, filepath.FromSlash(name)))
+	}
+	if IsArchive(path) {
+		files, err := loadArchive(path)
+		if err != nil {
+			return nil, err
+		}
+		read = func(name string) ([]byte, error) {
+			data, ok := files[name]
+			if !ok {
+				return nil, fmt.Errorf("%s不存在", name)
+			}
+			return data, nil
+		}
+	}
+	buff, err := read(manifestName)
+	if err != nil {
+		return nil, fmt.Errorf("读取快照描述文件失败:%v", err)
+	}
+	s := &Snapshot{}
+	if err = json.Unmarshal(buff, s); err != nil {
+		return nil, fmt.Errorf("快照描述文件格式错误:%v", err)
+	}
+	for _, n := range s.Nodes {
+		if n.Data, err = read(s.fileName(n.Path)); err != nil {
+			return nil, fmt.Errorf("读取节点%s失败:%v", n.Path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Snapshot) fileName(path string) string {
+	name := strings.TrimLeft(path, "/")
+	if s.hasChildren(path) {
+		return name + "/.init"
+	}
+	return name
+}
+
+func (s *Snapshot) files() (map[string][]byte, error) {
+	manifest, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{manifestName: manifest}
+	for _, n := range s.Nodes {
+		files[s.fileName(n.Path)] = n.Data
+	}
+	return files, nil
+}
+
+func saveArchive(path string, files map[string][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+	for name, data := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func loadArchive(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(hdr.Name, "./")] = data
+	}
+}
@@ -0,0 +1,160 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sereiner/parrot/registry"
+)
+
+//MaxRetry 更新节点发生版本号冲突时的最大重试次数
+var MaxRetry = 3
+
+//Node 快照节点
+type Node struct {
+	Path string `json:"path"`
+	Data []byte `json:"-"`
+}
+
+//Snapshot 平台配置快照,包含服务器主配置及子配置,平台变量配置与安装包配置
+type Snapshot struct {
+	PlatName    string   `json:"plat"`
+	SystemName  string   `json:"system"`
+	ServerTypes []string `json:"server_types"`
+	ClusterName string   `json:"cluster"`
+	Nodes       []*Node  `json:"nodes"`
+}
+
+//Record 导入记录
+type Record struct {
+	Path   string
+	Action string
+}
+
+const (
+	//ActionCreate 新建节点
+	ActionCreate = "create"
+	//ActionUpdate 修改节点
+	ActionUpdate = "update"
+	//ActionSkip 节点已存在或未变化
+	ActionSkip = "skip"
+)
+
+//Export 从注册中心导出平台配置,包括/plat/sys/type/cluster/conf/*,/plat/var/*/*,/plat/package/*
+func Export(rgst registry.IRegistry, platName string, systemName string, serverTypes []string, clusterName string) (*Snapshot, error) {
+	s := &Snapshot{
+		PlatName:    platName,
+		SystemName:  systemName,
+		ServerTypes: serverTypes,
+		ClusterName: clusterName,
+		Nodes:       make([]*Node, 0, 8),
+	}
+	roots := make([]string, 0, len(serverTypes)+2)
+	for _, tp := range serverTypes {
+		roots = append(roots, registry.Join("/", platName, systemName, tp, clusterName, "conf"))
+	}
+	roots = append(roots, registry.Join("/", platName, "var"), registry.Join("/", platName, "package"))
+	for _, root := range roots {
+		if err := s.walk(rgst, root); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Path < s.Nodes[j].Path })
+	return s, nil
+}
+
+//walk 递归获取节点及其子节点,仅保存有数据的节点和叶子节点
+func (s *Snapshot) walk(rgst registry.IRegistry, path string) error {
+	b, err := rgst.Exists(path)
+	if err != nil {
+		return fmt.Errorf("导出节点%s失败:%v", path, err)
+	}
+	if !b {
+		return nil
+	}
+	//本地文件系统的叶子节点无法获取子节点
+	children, _, _ := rgst.GetChildren(path)
+	data, _, err := rgst.GetValue(path)
+	if err == nil && (len(data) > 0 || len(children) == 0) {
+		s.Nodes = append(s.Nodes, &Node{Path: path, Data: data})
+	}
+	for _, c := range children {
+		if err := s.walk(rgst, registry.Join(path, c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Import 将快照导入注册中心,节点路径中的平台,系统,集群名称替换为指定名称。cover为false时跳过已存在的节点
+func (s *Snapshot) Import(rgst registry.IRegistry, platName string, systemName string, clusterName string, cover bool) ([]*Record, error) {
+	records := make([]*Record, 0, len(s.Nodes))
+	for _, n := range s.Nodes {
+		path := s.rename(n.Path, platName, systemName, clusterName)
+		rpath := path
+		if s.hasChildren(n.Path) && !rgst.CanWirteDataInDir() {
+			rpath = registry.Join(path, ".init")
+		}
+		action, err := importNode(rgst, rpath, n.Data, cover)
+		if err != nil {
+			return records, fmt.Errorf("导入节点%s失败:%v", path, err)
+		}
+		records = append(records, &Record{Path: path, Action: action})
+	}
+	return records, nil
+}
+
+func importNode(rgst registry.IRegistry, path string, data []byte, cover bool) (string, error) {
+	b, err := rgst.Exists(path)
+	if err != nil {
+		return "", err
+	}
+	if !b {
+		return ActionCreate, rgst.CreatePersistentNode(path, string(data))
+	}
+	if !cover {
+		return ActionSkip, nil
+	}
+	for i := 0; ; i++ {
+		current, version, err := rgst.GetValue(path)
+		if err != nil {
+			return "", err
+		}
+		if string(current) == string(data) {
+			return ActionSkip, nil
+		}
+		err = rgst.Update(path, string(data), version)
+		if err == nil {
+			return ActionUpdate, nil
+		}
+		if !registry.IsVersionConflict(err) || i >= MaxRetry {
+			return "", err
+		}
+	}
+}
+
+//rename 将源平台,系统,集群名称替换为目标名称
+func (s *Snapshot) rename(path string, platName string, systemName string, clusterName string) string {
+	for _, tp := range s.ServerTypes {
+		src := registry.Join("/", s.PlatName, s.SystemName, tp, s.ClusterName, "conf")
+		if path == src || strings.HasPrefix(path, src+"/") {
+			return registry.Join("/", platName, systemName, tp, clusterName, "conf", strings.TrimPrefix(path, src))
+		}
+	}
+	src := registry.Join("/", s.PlatName)
+	if strings.HasPrefix(path, src+"/") {
+		return registry.Join("/", platName, strings.TrimPrefix(path, src))
+	}
+	return path
+}
+
+//hasChildren 快照中是否包含指定节点的子节点
+func (s *Snapshot) hasChildren(path string) bool {
+	for _, n := range s.Nodes {
+		if strings.HasPrefix(n.Path, path+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logger "github.com/sereiner/library/log"
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/registry"
+	_ "github.com/sereiner/parrot/registry/local"
+	"github.com/sereiner/parrot/registry/mem"
+)
+
+func newTestSource() registry.IRegistry {
+	rgst := mem.New("snapshot-source")
+	rgst.CreatePersistentNode("/test/order/api/t/conf", `{"address":":8090"}`)
+	rgst.CreatePersistentNode("/test/order/api/t/conf/router", `{"routers":[]}`)
+	rgst.CreatePersistentNode("/test/order/api/t2/conf", `{"address":":9090"}`)
+	rgst.CreatePersistentNode("/test/var/db/db", `{"provider":"ora"}`)
+	rgst.CreatePersistentNode("/test/package/order/1.0.0", `{"url":"http://192.168.0.2/order.tar.gz"}`)
+	return rgst
+}
+
+func TestExportImport(t *testing.T) {
+	s, err := Export(newTestSource(), "test", "order", []string{"api"}, "t")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, len(s.Nodes), 4)
+
+	dir, _ := ioutil.TempDir("", "parrot-snapshot")
+	defer os.RemoveAll(dir)
+	for _, path := range []string{filepath.Join(dir, "snap"), filepath.Join(dir, "snap.tar.gz")} {
+		err = s.Save(path)
+		ut.Expect(t, err, nil)
+		ns, err := Load(path)
+		ut.Expect(t, err, nil)
+		ut.Expect(t, len(ns.Nodes), len(s.Nodes))
+
+		dst := mem.New("snapshot-" + path)
+		records, err := ns.Import(dst, "prod", "order", "p", false)
+		ut.Expect(t, err, nil)
+		ut.Expect(t, len(records), 4)
+		data, _, _ := dst.GetValue("/prod/order/api/p/conf/router")
+		ut.Expect(t, string(data), `{"routers":[]}`)
+		data, _, _ = dst.GetValue("/prod/var/db/db")
+		ut.Expect(t, string(data), `{"provider":"ora"}`)
+	}
+}
+
+func TestImportFS(t *testing.T) {
+	s, err := Export(newTestSource(), "test", "order", []string{"api"}, "t")
+	ut.Expect(t, err, nil)
+	dir, _ := ioutil.TempDir("", "parrot-snapshot")
+	defer os.RemoveAll(dir)
+	dst, err := registry.NewRegistryWithAddress("fs://"+dir, logger.New("snapshot"))
+	ut.Expect(t, err, nil)
+
+	_, err = s.Import(dst, "test", "order", "t", false)
+	ut.Expect(t, err, nil)
+	data, _, _ := dst.GetValue("/test/order/api/t/conf")
+	ut.Expect(t, string(data), `{"address":":8090"}`)
+
+	//已存在的节点在覆盖模式下更新
+	s.Nodes[0].Data = []byte(`{"address":":8080"}`)
+	records, err := s.Import(dst, "test", "order", "t", true)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, records[0].Action, ActionUpdate)
+	ut.Expect(t, records[1].Action, ActionSkip)
+
+	//从本地文件系统导出
+	ns, err := Export(dst, "test", "order", []string{"api"}, "t")
+	ut.Expect(t, err, nil)
+	ut.Expect(t, len(ns.Nodes), 4)
+	ut.Expect(t, string(ns.Nodes[0].Data), `{"address":":8080"}`)
+}
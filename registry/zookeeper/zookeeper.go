@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	gozk "github.com/samuel/go-zookeeper/zk"
 	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/library/zk"
 	"github.com/sereiner/parrot/registry"
@@ -23,7 +24,22 @@ func (z *zkRegistry) Resolve(servers []string, u string, p string, log logger.IL
 		return nil, err
 	}
 	err = zclient.Connect()
-	return zclient, err
+	return &zkClient{ZookeeperClient: zclient}, err
+}
+
+//zkClient zookeeper客户端,将版本号冲突转换为registry.ErrVersionConflict
+type zkClient struct {
+	*zk.ZookeeperClient
+}
+
+//Update 更新节点的值,version小于0时不检查版本号
+func (c *zkClient) Update(path string, data string, version int32) (err error) {
+	err = c.ZookeeperClient.Update(path, data, version)
+	if err != gozk.ErrBadVersion {
+		return err
+	}
+	_, current, _ := c.ZookeeperClient.GetValue(path)
+	return registry.NewVersionConflictError(path, version, current)
 }
 
 func init() {
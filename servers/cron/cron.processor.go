@@ -9,6 +9,7 @@ import (
 	"github.com/sereiner/library/redis"
 	"github.com/sereiner/library/utility"
 	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+	"github.com/sereiner/parrot/servers/pkg/drain"
 )
 
 //Processor 任务处理程序
@@ -27,6 +28,7 @@ type Processor struct {
 	redisSetting string
 	redisClient  *redis.Client
 	historyNode  string
+	tracker      *drain.Tracker
 }
 
 //NewProcessor 创建processor
@@ -39,6 +41,7 @@ func NewProcessor(redisSetting string, historyNode string) (p *Processor, err er
 		startTime:    time.Now(),
 		redisSetting: redisSetting,
 		historyNode:  historyNode,
+		tracker:      drain.NewTracker(),
 	}
 	p.slots = make([]cmap.ConcurrentMap, p.length, p.length)
 	for i := 0; i < p.length; i++ {
@@ -85,6 +88,10 @@ func (s *Processor) handle(task iCronTask) error {
 		return nil
 	}
 	if !s.isPause {
+		if !s.tracker.Begin() {
+			return nil
+		}
+		defer s.tracker.End()
 		task.AddExecuted()
 		rw, err := s.Dispatcher.HandleRequest(task)
 		if err != nil {
@@ -133,6 +140,7 @@ func (s *Processor) Add(task iCronTask, r bool) (offset int, round int, err erro
 		return -1, -1, errors.New("next time less than now.2")
 	}
 	task.SetRound(round)
+	task.SetScheduledTime(nextTime)
 	s.slots[offset].Set(utility.GetGUID(), task)
 	if r {
 		if !s.Dispatcher.Find(task.GetService()) {
@@ -163,6 +171,11 @@ func (s *Processor) Resume() error {
 	return nil
 }
 
+//Drain 等待正在执行的任务完成,超时返回未完成的任务数,此后到期的任务不再执行
+func (s *Processor) Drain(timeout time.Duration) int {
+	return s.tracker.Wait(timeout)
+}
+
 //Close 退出
 func (s *Processor) Close() {
 	s.lock.Lock()
@@ -17,13 +17,15 @@ func (s *ApiServer) getHandler(routers []*conf.Router) (x.Handler, error) {
 	}
 	engine := gin.New()
 	engine.Use(gin.Recovery())
-	engine.Use(middleware.Logging(s.conf)) //记录请求日志
+	engine.Use(middleware.Drain(s.tracker)) //记录处理中的请求
+	engine.Use(middleware.Logging(s.conf))  //记录请求日志
 	engine.Use(middleware.Recovery())
 	engine.Use(s.option.metric.Handle())        //生成metric报表
 	engine.Use(middleware.Host(s.conf))         // 检查主机头是否合法
 	engine.Use(middleware.Static(s.conf))       //处理静态文件
 	engine.Use(middleware.AjaxRequest(s.conf))  //过滤非ajax请求
 	engine.Use(middleware.JwtAuth(s.conf))      //jwt安全认证
+	engine.Use(middleware.Limit(s.conf))        //请求限流
 	engine.Use(middleware.CircuitBreak(s.conf)) //服务熔断配置
 	//engine.Use(middleware.Body())               //处理请求form
 	engine.Use(middleware.APIResponse(s.conf)) //处理返回值
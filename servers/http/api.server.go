@@ -16,6 +16,8 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers"
 	"github.com/sereiner/parrot/servers/http/middleware"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 //ApiServer api服务器
@@ -23,6 +25,8 @@ type ApiServer struct {
 	*option
 	conf    *conf.MetadataConf
 	engine  *x.Server
+	handler *routerHandler
+	tracker *drain.Tracker
 	running string
 	proto   string
 	host    string
@@ -31,7 +35,7 @@ type ApiServer struct {
 
 //NewApiServer 创建api服务器
 func NewApiServer(name string, addr string, routers []*conf.Router, opts ...Option) (t *ApiServer, err error) {
-	t = &ApiServer{conf: &conf.MetadataConf{
+	t = &ApiServer{tracker: drain.NewTracker(), conf: &conf.MetadataConf{
 		Name: name,
 		Type: "api",
 	}}
@@ -58,8 +62,10 @@ func NewApiServer(name string, addr string, routers []*conf.Router, opts ...Opti
 		WriteTimeout:      time.Second * time.Duration(t.option.writeTimeout),
 		MaxHeaderBytes:    1 << 20,
 	}
+	t.handler = &routerHandler{}
+	t.engine.Handler = t.handler
 	if routers != nil {
-		t.engine.Handler, err = t.getHandler(routers)
+		err = t.SetRouters(routers)
 	}
 	t.SetTrace(t.showTrace)
 	return
@@ -67,20 +73,25 @@ func NewApiServer(name string, addr string, routers []*conf.Router, opts ...Opti
 
 // Run the http server
 func (s *ApiServer) Run() error {
+	l, err := graceful.Listen(s.engine.Addr)
+	if err != nil {
+		s.running = servers.ST_STOP
+		return err
+	}
 	s.running = servers.ST_RUNNING
 	errChan := make(chan error, 1)
 	switch len(s.tls) {
 	case 2:
 		s.proto = "https"
 		go func(ch chan error) {
-			if err := s.engine.ListenAndServeTLS(s.tls[0], s.tls[1]); err != nil {
+			if err := s.engine.ServeTLS(l, s.tls[0], s.tls[1]); err != nil {
 				ch <- err
 			}
 		}(errChan)
 	default:
 		s.proto = "http"
 		go func(ch chan error) {
-			if err := s.engine.ListenAndServe(); err != nil {
+			if err := s.engine.Serve(l); err != nil {
 				ch <- err
 			}
 		}(errChan)
@@ -97,12 +108,19 @@ func (s *ApiServer) Run() error {
 
 //Shutdown 关闭服务器
 func (s *ApiServer) Shutdown(timeout time.Duration) {
+	defer s.StopTracing()
+	defer s.CloseLimiter()
 	if s.engine != nil {
 		s.metric.Stop()
 		s.running = servers.ST_STOP
+		start := time.Now()
 		ctx, cannel := context.WithTimeout(context.Background(), timeout)
 		defer cannel()
-		if err := s.engine.Shutdown(ctx); err != nil {
+		err := s.engine.Shutdown(ctx)
+		if n := s.tracker.Wait(timeout - time.Since(start)); n > 0 {
+			s.Warnf("%s:%d个请求未在%v内处理完成", s.conf.Name, n, timeout)
+		}
+		if err != nil {
 			if err == x.ErrServerClosed {
 				s.Infof("%s:已关闭", s.conf.Name)
 				return
@@ -5,12 +5,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/sereiner/library/archiver"
+	"github.com/sereiner/parrot/component"
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers"
 	"github.com/sereiner/parrot/servers/http/middleware"
+	"github.com/sereiner/parrot/servers/pkg/limiter"
 )
 
 //waitRemoveDir 等待移除的静态文件
@@ -32,7 +35,7 @@ func SetMetric(set ISetMetric, cnf conf.IServerConf) (enable bool, err error) {
 	if err == conf.ErrNoSetting {
 		metric.Disable = true
 	} else {
-		if b, err := govalidator.ValidateStruct(&metric); !b {
+		if err := metric.Validate(); err != nil {
 			err = fmt.Errorf("metric配置有误:%v", err)
 			return false, err
 		}
@@ -41,6 +44,28 @@ func SetMetric(set ISetMetric, cnf conf.IServerConf) (enable bool, err error) {
 	return !metric.Disable && err == nil, err
 }
 
+//ISetTracing 设置链路跟踪
+type ISetTracing interface {
+	SetTracing(*conf.Tracing) error
+}
+
+//SetTracing 设置链路跟踪
+func SetTracing(set ISetTracing, cnf conf.IServerConf) (enable bool, err error) {
+	var tracing conf.Tracing
+	_, err = cnf.GetSubObject("tracing", &tracing)
+	if err != nil && err != conf.ErrNoSetting {
+		return false, err
+	}
+	if err == conf.ErrNoSetting {
+		tracing.Disable = true
+	} else if err := tracing.Validate(); err != nil {
+		err = fmt.Errorf("tracing配置有误:%v", err)
+		return false, err
+	}
+	err = set.SetTracing(&tracing)
+	return !tracing.Disable && err == nil, err
+}
+
 type ISetStatic interface {
 	SetStatic(static *conf.Static) error
 }
@@ -196,6 +221,59 @@ func SetCircuitBreaker(set ISetCircuitBreaker, cnf conf.IServerConf) (enable boo
 	return err == nil && !breaker.Disable, err
 }
 
+//ISetLimiter 设置限流
+type ISetLimiter interface {
+	CloseLimiter() error
+	SetLimiter(*limiter.Limiter) error
+}
+
+//releaseLimiter 延迟关闭已替换的限流组件,等待已获取该组件的请求完成
+func releaseLimiter(l *limiter.Limiter) {
+	time.AfterFunc(component.ReleaseDelay, func() { l.Close() })
+}
+
+//IsLimiterChanged limiter节点或其引用的var/cache节点是否发生变化
+func IsLimiterChanged(o conf.IServerConf, n conf.IServerConf) bool {
+	comparer := conf.NewComparer(o, n)
+	for _, name := range comparer.GetChangedSubConfs() {
+		if name == "limiter" {
+			return true
+		}
+	}
+	var l conf.Limiter
+	if _, err := n.GetSubObject("limiter", &l); err != nil || l.Cache == "" {
+		return false
+	}
+	for _, name := range comparer.GetChangedVarConfs() {
+		if name == "cache/"+l.Cache {
+			return true
+		}
+	}
+	return false
+}
+
+//SetLimiter 设置限流配置
+func SetLimiter(set ISetLimiter, cnf conf.IServerConf) (enable bool, err error) {
+	var l conf.Limiter
+	if _, err = cnf.GetSubObject("limiter", &l); err == conf.ErrNoSetting || l.Disable {
+		return false, set.CloseLimiter()
+	}
+	if err != nil {
+		return false, err
+	}
+	if err = l.Validate(); err != nil {
+		err = fmt.Errorf("limiter配置有误:%v", err)
+		return false, err
+	}
+	lm, err := limiter.New(&l, cnf)
+	if err != nil {
+		err = fmt.Errorf("limiter配置有误:%v", err)
+		return false, err
+	}
+	err = set.SetLimiter(lm)
+	return err == nil, err
+}
+
 //---------------------------------------------------------------------------
 //-------------------------------header---------------------------------------
 //---------------------------------------------------------------------------
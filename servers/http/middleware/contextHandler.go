@@ -15,6 +15,7 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/context"
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/trace"
 )
 
 func getUUID(c *gin.Context) string {
@@ -93,6 +94,18 @@ func getCTX(c *gin.Context) *context.Context {
 	}
 	return result.(*context.Context)
 }
+func getTracer(cnf *conf.MetadataConf) *trace.Tracer {
+	t, _ := cnf.GetMetadata("__tracer_").(*trace.Tracer)
+	return t
+}
+func setSpan(c *gin.Context, s *trace.Span) {
+	c.Set("__trace_span_", s)
+}
+func getSpan(c *gin.Context) *trace.Span {
+	s, _ := c.Get("__trace_span_")
+	span, _ := s.(*trace.Span)
+	return span
+}
 func getTrace(cnf *conf.MetadataConf) bool {
 	return cnf.GetMetadata("show-trace").(bool)
 }
@@ -180,6 +193,7 @@ func makeExtData(c *gin.Context) map[string]interface{} {
 	input["__method_"] = strings.ToLower(c.Request.Method)
 	input["__header_"] = c.Request.Header
 	input["__is_circuit_breaker_"] = getIsCircuitBreaker(c)
+	input["__trace_span_"] = getSpan(c)
 	input["__jwt_"] = func() interface{} {
 		return getJWTRaw(c)
 	}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+)
+
+//Drain 记录正在处理的请求,服务器关闭后拒绝新请求
+func Drain(t *drain.Tracker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !t.Begin() {
+			ctx.Header("Connection", "close")
+			ctx.AbortWithStatus(503)
+			return
+		}
+		defer t.End()
+		ctx.Next()
+	}
+}
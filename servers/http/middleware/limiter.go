@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/servers/pkg/limiter"
+)
+
+//Limit 按路由与客户端限流,超出限制时返回429并通过Retry-After告知需等待的秒数
+func Limit(cnf *conf.MetadataConf) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		l, ok := cnf.GetMetadata("__limiter_").(*limiter.Limiter)
+		if !ok || l == nil {
+			ctx.Next()
+			return
+		}
+		allow, retryAfter, err := l.Allow(ctx.Request.URL.Path, func(r *conf.LimitRule) string {
+			return getLimitKey(ctx, l, r)
+		})
+		if err != nil {
+			getLogger(ctx).Warnf("限流检查失败,已放行:%v", err)
+		}
+		if allow {
+			ctx.Next()
+			return
+		}
+		getLogger(ctx).Warnf("请求超过限制:%s", ctx.Request.URL.Path)
+		setHeader(cnf, ctx)
+		ctx.Header("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+		ctx.AbortWithStatus(429)
+	}
+}
+
+//getLimitKey 获取客户端标识
+func getLimitKey(ctx *gin.Context, l *limiter.Limiter, r *conf.LimitRule) string {
+	switch r.By {
+	case "jwt":
+		data := getJWTRaw(ctx)
+		if m, ok := data.(map[string]interface{}); ok {
+			if v, ok := m[r.Name]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+		}
+		return ""
+	case "header":
+		return ctx.GetHeader(r.Name)
+	case "appkey":
+		name := r.Name
+		if name == "" {
+			name = "appkey"
+		}
+		if v := ctx.GetHeader(name); v != "" {
+			return v
+		}
+		if v, ok := ctx.GetQuery(name); ok {
+			return v
+		}
+		return ctx.PostForm(name)
+	default:
+		return l.GetClientIP(ctx.Request)
+	}
+}
@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/trace"
 )
 
 //Logging 记录日志
@@ -24,11 +26,19 @@ func Logging(conf *conf.MetadataConf) gin.HandlerFunc {
 		log := logger.GetSession(conf.Name, uuid, "biz", strings.Replace(strings.Trim(ctx.Request.URL.Path, "/"), "/", "_", -1))
 		log.Info(conf.Type+".request", ctx.Request.Method, p, "from", ctx.ClientIP())
 		setLogger(ctx, log)
+		span := getTracer(conf).Start(conf.Type+" "+ctx.Request.URL.Path, trace.KindServer, ctx.GetHeader(trace.HeaderName))
+		span.SetAttribute("server.name", conf.Name).SetAttribute("http.method", ctx.Request.Method).SetAttribute("http.target", p)
+		setSpan(ctx, span)
+		defer span.End()
 		ctx.Next()
 
 		v, _ := getResponseRaw(ctx)
 
 		statusCode := ctx.Writer.Status()
+		span.SetAttribute("http.status_code", statusCode)
+		if statusCode >= 500 {
+			span.SetError(fmt.Errorf("status:%d", statusCode))
+		}
 		if statusCode >= 200 && statusCode < 400 {
 			log.Info(conf.Type+".response", ctx.Request.Method, p, statusCode, getExt(ctx), time.Since(start), v)
 		} else {
@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sereiner/library/concurrent/cmap"
@@ -11,6 +13,7 @@ import (
 	"github.com/sereiner/library/net"
 	"github.com/sereiner/library/xsync"
 	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/servers/pkg/prometheus"
 	"github.com/sereiner/parrot/servers/pkg/timer"
 )
 
@@ -36,6 +39,8 @@ type Metric struct {
 	timer           *timer.Timer
 	done            bool
 	closeChan       chan struct{}
+	prometheus      *prometheus.Server
+	prometheusPath  atomic.Value
 }
 
 //NewMetric new metric
@@ -64,6 +69,11 @@ func (m *Metric) Stop() {
 	if m.timer != nil {
 		m.timer.Close()
 	}
+	if m.prometheus != nil {
+		m.prometheus.Close()
+		m.prometheus = nil
+	}
+	m.prometheusPath.Store("")
 }
 
 //Restart restart metric
@@ -93,6 +103,19 @@ func (m *Metric) Restart(host string, dataBase string, userName string, password
 	m.timer.Start()
 	return nil
 }
+
+//SetPrometheus 提供prometheus格式的metrics接口,address为空时由当前服务器处理path请求
+func (m *Metric) SetPrometheus(path string, address string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if address == "" {
+		m.prometheusPath.Store(path)
+		return nil
+	}
+	m.prometheus, err = prometheus.Start(address, path, m.currentRegistry)
+	return err
+}
+
 func (m *Metric) collectSys() {
 	if !m.ticket.Wait() {
 		return
@@ -107,6 +130,13 @@ func (m *Metric) collectSys() {
 func (m *Metric) Handle() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		url := ctx.Request.URL.Path
+		if path, _ := m.prometheusPath.Load().(string); path != "" && path == url {
+			buff := bytes.NewBuffer(nil)
+			prometheus.Write(buff, m.currentRegistry)
+			ctx.Data(200, prometheus.ContentType, buff.Bytes())
+			ctx.Abort()
+			return
+		}
 		conterName := metrics.MakeName(m.conf.Type+".server.request", metrics.WORKING, "name", m.conf.Name, "host", m.ip, "url", url) //堵塞计数
 		timerName := metrics.MakeName(m.conf.Type+".server.request", metrics.TIMER, "name", m.conf.Name, "host", m.ip, "url", url)    //堵塞计数
 		requestName := metrics.MakeName(m.conf.Type+".server.request", metrics.QPS, "name", m.conf.Name, "host", m.ip, "url", url)    //请求数
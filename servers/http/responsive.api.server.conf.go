@@ -1,8 +1,6 @@
 package http
 
 import (
-	"fmt"
-
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers"
 )
@@ -40,21 +38,11 @@ func (w *ApiResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if !comparer.IsChanged() {
 		return false, nil
 	}
-	if comparer.IsVarChanged() {
-		return true, nil
-	}
 
 	if comparer.IsValueChanged("status", "address", "host", "dn", "rTimeout", "wTimeout", "rhTimeout") {
 		return true, nil
 	}
-	ok, err := comparer.IsRequiredSubConfChanged("router")
-	if ok {
-		return true, nil
-	}
-	if err != nil {
-		return false, fmt.Errorf("路由未配置或配置有误:%v", err)
-	}
-	if ok := comparer.IsSubConfChanged("app"); ok {
+	if ok := comparer.IsSubConfChanged("app"); ok && !conf.IsAppWatched(cnf.GetServerType()) {
 		return ok, nil
 	}
 	if ok := comparer.IsSubConfChanged("circuit"); ok {
@@ -67,8 +55,8 @@ func (w *ApiResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 func (w *ApiResponsiveServer) SetConf(restart bool, cnf conf.IServerConf) (err error) {
 
 	var ok bool
-	//设置路由
-	if restart {
+	//设置路由,路由变化时替换请求处理程序,不重启服务器
+	if restart || conf.NewComparer(w.currentConf, cnf).IsSubConfChanged("router") {
 		if _, err := SetHttpRouters(w.engine, w.server, cnf); err != nil {
 			return err
 		}
@@ -91,6 +79,14 @@ func (w *ApiResponsiveServer) SetConf(restart bool, cnf conf.IServerConf) (err e
 	}
 	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "熔断设置")
 
+	//设置限流,限流配置或引用的缓存配置变化时重建限流组件
+	if restart || IsLimiterChanged(w.currentConf, cnf) {
+		if ok, err = SetLimiter(w.server, cnf); err != nil {
+			return err
+		}
+		servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "限流设置")
+	}
+
 	//设置jwt安全认证
 	if ok, err = SetJWT(w.server, cnf); err != nil {
 		return err
@@ -109,6 +105,12 @@ func (w *ApiResponsiveServer) SetConf(restart bool, cnf conf.IServerConf) (err e
 	}
 	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "metric设置")
 
+	//设置链路跟踪
+	if ok, err = SetTracing(w.server, cnf); err != nil {
+		return err
+	}
+	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "链路跟踪设置")
+
 	//设置host
 	if ok, err = SetHosts(w.server, cnf); err != nil {
 		return err
@@ -13,6 +13,9 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/engines"
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+	"github.com/sereiner/parrot/servers/pkg/limiter"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 type IServer interface {
@@ -22,6 +25,8 @@ type IServer interface {
 	GetAddress(h ...string) string
 	CloseCircuitBreaker() error
 	SetCircuitBreaker(*conf.CircuitBreaker) error
+	CloseLimiter() error
+	SetLimiter(*limiter.Limiter) error
 
 	SetRouters(routers []*conf.Router) (err error)
 	SetJWT(auth *conf.Auth) error
@@ -29,6 +34,7 @@ type IServer interface {
 	SetHosts(conf.Hosts) error
 	SetStatic(*conf.Static) error
 	SetMetric(*conf.Metric) error
+	SetTracing(*conf.Tracing) error
 	SetHeader(conf.Headers) error
 	StopMetric() error
 }
@@ -84,6 +90,8 @@ func NewApiResponsiveServer(registryAddr string, cnf conf.IServerConf, logger *l
 
 //Restart 重启服务器
 func (w *ApiResponsiveServer) Restart(cnf conf.IServerConf) (err error) {
+	//重启期间保留监听端口,新连接在端口队列中等待
+	defer graceful.Hold()()
 	w.Shutdown()
 	time.Sleep(time.Second)
 	w.done = false
@@ -136,7 +144,7 @@ func (w *ApiResponsiveServer) Shutdown() {
 		close(w.closeChan)
 	})
 	w.unpublish()
-	w.server.Shutdown(10 * time.Second)
+	w.server.Shutdown(drain.GetTimeout(w.currentConf))
 	if w.engine != nil {
 		w.engine.Close()
 	}
@@ -155,6 +163,14 @@ func (w *ApiResponsiveServer) GetStatus() string {
 	return w.server.GetStatus()
 }
 
+//Ready 检查服务器是否就绪
+func (w *ApiResponsiveServer) Ready() error {
+	if w.done {
+		return servers.ErrServerClosed
+	}
+	return w.engine.CheckHealth()
+}
+
 //GetServices 获取服务列表
 func (w *ApiResponsiveServer) GetServices() map[string][]string {
 	return w.engine.GetServices()
@@ -8,6 +8,7 @@ import (
 
 	"github.com/sereiner/library/jsons"
 	"github.com/sereiner/parrot/registry"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 //publish 将当前服务器的节点信息发布到注册中心
@@ -107,6 +108,11 @@ func (w *ApiResponsiveServer) checkPubPath() {
 func (w *ApiResponsiveServer) unpublish() {
 	w.pubLock.Lock()
 	defer w.pubLock.Unlock()
+	//平滑重启后新进程已发布相同地址的节点,不再删除
+	if graceful.IsHandedOff() {
+		w.pubs = make(map[string]string)
+		return
+	}
 	for path := range w.pubs {
 		w.engine.GetRegistry().Delete(path)
 	}
@@ -1,8 +1,6 @@
 package http
 
 import (
-	"fmt"
-
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers"
 )
@@ -40,23 +38,13 @@ func (w *WebResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if !comparer.IsChanged() {
 		return false, nil
 	}
-	if comparer.IsVarChanged() {
-		return true, nil
-	}
 	if comparer.IsValueChanged("status", "address", "host", "rTimeout", "wTimeout", "rhTimeout") {
 		return true, nil
 	}
-	ok, err := comparer.IsRequiredSubConfChanged("router")
-	if ok {
-		return ok, nil
-	}
-	if err != nil {
-		return ok, fmt.Errorf("路由未配置或配置有误:%v", err)
-	}
-	if ok := comparer.IsSubConfChanged("app"); ok {
+	if ok := comparer.IsSubConfChanged("view"); ok {
 		return ok, nil
 	}
-	if ok := comparer.IsSubConfChanged("view"); ok {
+	if ok := comparer.IsSubConfChanged("app"); ok && !conf.IsAppWatched(cnf.GetServerType()) {
 		return ok, nil
 	}
 	if ok := comparer.IsSubConfChanged("circuit"); ok {
@@ -10,6 +10,7 @@ import (
 	"github.com/sereiner/parrot/component"
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/engines"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 //WebResponsiveServer web 响应式服务器
@@ -53,6 +54,8 @@ func NewWebResponsiveServer(registryAddr string, cnf conf.IServerConf, logger *l
 
 //Restart 重启服务器
 func (w *WebResponsiveServer) Restart(cnf conf.IServerConf) (err error) {
+	//重启期间保留监听端口,新连接在端口队列中等待
+	defer graceful.Hold()()
 	w.Shutdown()
 	time.Sleep(time.Second)
 	w.closeChan = make(chan struct{})
@@ -0,0 +1,29 @@
+package http
+
+import (
+	x "net/http"
+	"sync/atomic"
+)
+
+//routerHandler 可替换的请求处理程序,路由变化时原子替换,正在处理的请求由原处理程序完成
+type routerHandler struct {
+	v atomic.Value
+}
+
+type handlerHolder struct {
+	x.Handler
+}
+
+//Store 替换请求处理程序
+func (h *routerHandler) Store(handler x.Handler) {
+	h.v.Store(handlerHolder{Handler: handler})
+}
+
+//ServeHTTP 使用当前处理程序处理请求,未设置路由时使用默认处理程序
+func (h *routerHandler) ServeHTTP(w x.ResponseWriter, r *x.Request) {
+	if holder, ok := h.v.Load().(handlerHolder); ok && holder.Handler != nil {
+		holder.ServeHTTP(w, r)
+		return
+	}
+	x.DefaultServeMux.ServeHTTP(w, r)
+}
@@ -18,13 +18,15 @@ func (s *WebServer) getHandler(routers []*conf.Router) (h x.Handler, err error)
 	}
 	s.gin = gin.New()
 	s.gin.Use(gin.Recovery())
-	s.gin.Use(middleware.Logging(s.conf)) //记录请求日志
+	s.gin.Use(middleware.Drain(s.tracker)) //记录处理中的请求
+	s.gin.Use(middleware.Logging(s.conf))  //记录请求日志
 	s.gin.Use(middleware.Recovery())
 
 	s.gin.Use(s.option.metric.Handle())       //生成metric报表
 	s.gin.Use(middleware.Host(s.conf))        // 检查主机头是否合法
 	s.gin.Use(middleware.Static(s.conf))      //处理静态文件
 	s.gin.Use(middleware.JwtAuth(s.conf))     //jwt安全认证
+	s.gin.Use(middleware.Limit(s.conf))       //请求限流
 	s.gin.Use(middleware.Body())              //处理请求form
 	s.gin.Use(middleware.WebResponse(s.conf)) //处理返回值
 	s.gin.Use(middleware.Header(s.conf))      //设置请求头
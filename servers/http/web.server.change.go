@@ -5,12 +5,18 @@ import (
 
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers/pkg/circuit"
+	"github.com/sereiner/parrot/servers/pkg/limiter"
+	"github.com/sereiner/parrot/trace"
 )
 
 //SetRouters 设置路由配置
 func (s *WebServer) SetRouters(routers []*conf.Router) (err error) {
-	s.engine.Handler, err = s.getHandler(routers)
-	return
+	handler, err := s.getHandler(routers)
+	if err != nil {
+		return err
+	}
+	s.handler.Store(handler)
+	return nil
 }
 
 //SetJWT Server
@@ -49,9 +55,17 @@ func (s *WebServer) SetMetric(metric *conf.Metric) error {
 	if metric.Disable {
 		return nil
 	}
-	if err := s.metric.Restart(metric.Host, metric.DataBase, metric.UserName, metric.Password, metric.Cron, s.Logger); err != nil {
-		err = fmt.Errorf("metric设置有误:%v", err)
-		return err
+	if metric.EnableInfluxDB() {
+		if err := s.metric.Restart(metric.Host, metric.DataBase, metric.UserName, metric.Password, metric.Cron, s.Logger); err != nil {
+			err = fmt.Errorf("metric设置有误:%v", err)
+			return err
+		}
+	}
+	if metric.EnablePrometheus() {
+		if err := s.metric.SetPrometheus(metric.GetPath(), metric.Address); err != nil {
+			err = fmt.Errorf("metric设置有误:%v", err)
+			return err
+		}
 	}
 	return nil
 }
@@ -68,6 +82,30 @@ func (s *WebServer) StopMetric() error {
 	return nil
 }
 
+//SetTracing 设置链路跟踪
+func (s *WebServer) SetTracing(t *conf.Tracing) error {
+	s.StopTracing()
+	if t.Disable {
+		return nil
+	}
+	tracer, err := trace.New(s.conf.Name, t, func(err error) { s.Warnf("链路跟踪推送失败:%v", err) })
+	if err != nil {
+		err = fmt.Errorf("tracing设置有误:%v", err)
+		return err
+	}
+	s.conf.SetMetadata("__tracer_", tracer)
+	return nil
+}
+
+//StopTracing 关闭链路跟踪,导出剩余的span
+func (s *WebServer) StopTracing() error {
+	if t, ok := s.conf.GetMetadata("__tracer_").(*trace.Tracer); ok {
+		s.conf.SetMetadata("__tracer_", nil)
+		return t.Close()
+	}
+	return nil
+}
+
 //SetView 设置view参数
 func (s *WebServer) SetView(view *conf.View) (err error) {
 	s.conf.SetMetadata("view", view)
@@ -91,3 +129,22 @@ func (s *WebServer) SetCircuitBreaker(c *conf.CircuitBreaker) error {
 	s.conf.SetMetadata("__circuit-breaker_", circuit.NewNamedCircuitBreakers(c))
 	return nil
 }
+
+//CloseLimiter 关闭限流
+func (s *WebServer) CloseLimiter() error {
+	if l, ok := s.conf.GetMetadata("__limiter_").(*limiter.Limiter); ok && l != nil {
+		s.conf.SetMetadata("__limiter_", nil)
+		releaseLimiter(l)
+	}
+	return nil
+}
+
+//SetLimiter 设置限流,替换当前的限流组件
+func (s *WebServer) SetLimiter(l *limiter.Limiter) error {
+	old, _ := s.conf.GetMetadata("__limiter_").(*limiter.Limiter)
+	s.conf.SetMetadata("__limiter_", l)
+	if old != nil {
+		releaseLimiter(old)
+	}
+	return nil
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers"
 	"github.com/sereiner/parrot/servers/http/middleware"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 //WebServer web服务器
@@ -21,6 +23,8 @@ type WebServer struct {
 	*option
 	conf    *conf.MetadataConf
 	engine  *x.Server
+	handler *routerHandler
+	tracker *drain.Tracker
 	gin     *gin.Engine
 	views   []string
 	running string
@@ -31,7 +35,7 @@ type WebServer struct {
 
 //NewWebServer 创建web服务器
 func NewWebServer(name string, addr string, routers []*conf.Router, opts ...Option) (t *WebServer, err error) {
-	t = &WebServer{conf: &conf.MetadataConf{
+	t = &WebServer{tracker: drain.NewTracker(), conf: &conf.MetadataConf{
 		Name: name,
 		Type: "web",
 	}}
@@ -58,8 +62,10 @@ func NewWebServer(name string, addr string, routers []*conf.Router, opts ...Opti
 		WriteTimeout:      time.Second * time.Duration(t.option.writeTimeout),
 		MaxHeaderBytes:    1 << 20,
 	}
+	t.handler = &routerHandler{}
+	t.engine.Handler = t.handler
 	if routers != nil {
-		t.engine.Handler, err = t.getHandler(routers)
+		err = t.SetRouters(routers)
 	}
 	t.SetTrace(t.showTrace)
 	return
@@ -68,10 +74,15 @@ func NewWebServer(name string, addr string, routers []*conf.Router, opts ...Opti
 // Run the http server
 func (s *WebServer) Run() error {
 	s.proto = "http"
+	l, err := graceful.Listen(s.engine.Addr)
+	if err != nil {
+		s.running = servers.ST_STOP
+		return err
+	}
 	s.running = servers.ST_RUNNING
 	errChan := make(chan error, 1)
 	go func(ch chan error) {
-		if err := s.engine.ListenAndServe(); err != nil {
+		if err := s.engine.Serve(l); err != nil {
 			ch <- err
 		}
 	}(errChan)
@@ -87,10 +98,15 @@ func (s *WebServer) Run() error {
 //RunTLS RunTLS server
 func (s *WebServer) RunTLS(certFile, keyFile string) error {
 	s.proto = "https"
+	l, err := graceful.Listen(s.engine.Addr)
+	if err != nil {
+		s.running = servers.ST_STOP
+		return err
+	}
 	s.running = servers.ST_RUNNING
 	errChan := make(chan error, 1)
 	go func(ch chan error) {
-		if err := s.engine.ListenAndServeTLS(certFile, keyFile); err != nil {
+		if err := s.engine.ServeTLS(l, certFile, keyFile); err != nil {
 			ch <- err
 		}
 	}(errChan)
@@ -105,12 +121,19 @@ func (s *WebServer) RunTLS(certFile, keyFile string) error {
 
 //Shutdown 关闭服务器
 func (s *WebServer) Shutdown(timeout time.Duration) {
+	defer s.StopTracing()
+	defer s.CloseLimiter()
 	if s.engine != nil {
 		s.metric.Stop()
 		s.running = servers.ST_STOP
+		start := time.Now()
 		ctx, cannel := context.WithTimeout(context.Background(), timeout)
 		defer cannel()
-		if err := s.engine.Shutdown(ctx); err != nil {
+		err := s.engine.Shutdown(ctx)
+		if n := s.tracker.Wait(timeout - time.Since(start)); n > 0 {
+			s.Warnf("%s:%d个请求未在%v内处理完成", s.conf.Name, n, timeout)
+		}
+		if err != nil {
 			if err == x.ErrServerClosed {
 				s.Infof("%s:已关闭", s.conf.Name)
 				return
@@ -15,7 +15,7 @@ func (s *MqcServer) getProcessor(addr string, raw string, queues []*conf.Queue)
 			err = fmt.Errorf("%v", err1)
 		}
 	}()
-	if engine, err = NewProcessor(addr, raw, queues); err != nil {
+	if engine, err = NewProcessor(addr, raw, queues, s.Logger); err != nil {
 		return nil,fmt.Errorf("NewProcessor err:%v",err)
 	}
 	engine.Use(middleware.Logging(s.conf)) //记录请求日志
@@ -3,12 +3,18 @@ package mqc
 import (
 	"fmt"
 	"sync"
+	"time"
 
+	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/library/mq"
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+	"github.com/sereiner/parrot/servers/pkg/drain"
 )
 
+//requeueDelay 等待处理中的消息完成后保留producer的时长,用于将此后收到的消息放回队列
+var requeueDelay = time.Second * 10
+
 type Processor struct {
 	*dispatcher.Dispatcher
 	mq.MQConsumer
@@ -21,12 +27,20 @@ type Processor struct {
 	addrss        string
 	raw           string
 	hasAddRouters bool
+	connErr       error
+	tracker       *drain.Tracker
+	logger        *logger.Logger
+	producer      mq.MQProducer
+	producerErr   error
+	producerLock  sync.Mutex
 }
 
 //NewProcessor 创建processor
-func NewProcessor(addrss, raw string, queues []*conf.Queue) (p *Processor, err error) {
+func NewProcessor(addrss, raw string, queues []*conf.Queue, logger *logger.Logger) (p *Processor, err error) {
 	p = &Processor{
 		Dispatcher: dispatcher.New(),
+		tracker:    drain.NewTracker(),
+		logger:     logger,
 		handles:    make(map[string]dispatcher.HandlerFunc),
 		addrss:     addrss,
 		raw:        raw,
@@ -52,12 +66,36 @@ func (s *Processor) Close() {
 	}
 }
 
+//Ready 检查消息服务器连接与订阅状态
+func (s *Processor) Ready() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.done || s.MQConsumer == nil {
+		return fmt.Errorf("未连接到消息服务器")
+	}
+	if s.connErr != nil {
+		return fmt.Errorf("连接消息服务器失败:%v", s.connErr)
+	}
+	if !s.isConsume && len(s.queues) > 0 {
+		return fmt.Errorf("未订阅消息队列")
+	}
+	return nil
+}
+
+//setConnError 记录连接消息服务器的错误
+func (s *Processor) setConnError(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.connErr = err
+}
+
 func (s *Processor) Consumes() (err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	if s.isConsume {
 		return nil
 	}
+	s.connErr = nil
 	if s.MQConsumer == nil {
 		s.once = sync.Once{}
 		s.MQConsumer, err = mq.NewMQConsumer(s.addrss, mq.WithRaw(s.raw), mq.WithQueueCount(len(s.queues)))
@@ -79,8 +117,66 @@ func (s *Processor) Consumes() (err error) {
 func (s *Processor) Consume(r *conf.Queue) error {
 	fmt.Println("queue",r.Queue)
 	return s.MQConsumer.Consume(r.Queue, r.Concurrency, func(m mq.IMessage) {
-		request := newMQRequest(r.Name, "GET", m.GetMessage())
+		if !s.tracker.Begin() {
+			s.requeue(r.Queue, m.GetMessage())
+			return
+		}
+		defer s.tracker.End()
+		request := newMQRequest(r.Queue, r.Name, "GET", m.GetMessage())
 		s.HandleRequest(request)
 		request = nil
 	})
 }
+
+//Drain 等待正在处理的消息完成,超时返回未完成的消息数,此后收到的消息将放回队列
+func (s *Processor) Drain(timeout time.Duration) int {
+	s.openProducer()
+	n := s.tracker.Wait(timeout)
+	time.AfterFunc(requeueDelay, s.closeProducer)
+	return n
+}
+
+//openProducer 创建放回消息使用的producer
+func (s *Processor) openProducer() {
+	s.producerLock.Lock()
+	defer s.producerLock.Unlock()
+	if s.producer != nil {
+		return
+	}
+	producer, err := mq.NewMQProducer(s.addrss, mq.WithRaw(s.raw))
+	if err == nil {
+		err = producer.Connect()
+	}
+	if err != nil {
+		s.producerErr = err
+		return
+	}
+	s.producer, s.producerErr = producer, nil
+}
+
+//closeProducer 关闭放回消息使用的producer;redis等producer未初始化备份通道,
+//Close会关闭nil通道导致panic,此类producer不调用Close
+func (s *Processor) closeProducer() {
+	s.producerLock.Lock()
+	producer := s.producer
+	s.producer, s.producerErr = nil, fmt.Errorf("producer已关闭")
+	s.producerLock.Unlock()
+	if producer != nil && producer.GetBackupMessage() != nil {
+		producer.Close()
+	}
+}
+
+//requeue 将服务器关闭后收到的消息放回队列
+func (s *Processor) requeue(queue string, msg string) {
+	s.producerLock.Lock()
+	producer, err := s.producer, s.producerErr
+	s.producerLock.Unlock()
+	if producer != nil {
+		err = producer.Send(queue, msg, time.Second*10)
+	}
+	if err != nil {
+		s.logger.Errorf("服务器已关闭,消息放回队列(%s)失败:%v,%s", queue, err, msg)
+		return
+	}
+	s.logger.Warnf("服务器已关闭,消息已放回队列(%s)", queue)
+}
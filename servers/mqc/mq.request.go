@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/sereiner/parrot/trace"
 )
 
 type mqRequest struct {
+	queue   string
 	service string
 	method  string
 	raw     string
@@ -14,14 +17,20 @@ type mqRequest struct {
 	header  map[string]string
 }
 
-func newMQRequest(service, method, raw string) *mqRequest {
+func newMQRequest(queue, service, method, raw string) *mqRequest {
 	r := &mqRequest{
+		queue:   queue,
 		service: service,
 		method:  method,
 		header:  make(map[string]string),
 		form:    make(map[string]interface{}),
 		raw:     raw,
 	}
+	//移除发送方附加的traceparent,业务获取的消息内容与发送时一致
+	if body, tp := trace.StripMessage(raw); tp != "" {
+		r.header[trace.HeaderName] = tp
+		r.raw = body
+	}
 	json.Unmarshal([]byte(r.raw), &r.form)
 	r.form["__body_"] = r.raw
 	return r
@@ -30,6 +39,9 @@ func newMQRequest(service, method, raw string) *mqRequest {
 func (m *mqRequest) GetService() string {
 	return fmt.Sprintf("/%s", strings.TrimPrefix(m.service, "/"))
 }
+func (m *mqRequest) GetQueue() string {
+	return m.queue
+}
 func (m *mqRequest) GetMethod() string {
 	return m.method
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/trace"
 )
 
 //SetMetric 重置metric
@@ -12,9 +13,17 @@ func (s *MqcServer) SetMetric(metric *conf.Metric) error {
 	if metric.Disable {
 		return nil
 	}
-	if err := s.metric.Restart(metric.Host, metric.DataBase, metric.UserName, metric.Password, metric.Cron, s.Logger); err != nil {
-		err = fmt.Errorf("metric设置有误:%v", err)
-		return err
+	if metric.EnableInfluxDB() {
+		if err := s.metric.Restart(metric.Host, metric.DataBase, metric.UserName, metric.Password, metric.Cron, s.Logger); err != nil {
+			err = fmt.Errorf("metric设置有误:%v", err)
+			return err
+		}
+	}
+	if metric.EnablePrometheus() {
+		if err := s.metric.SetPrometheus(metric.GetPath(), metric.Address); err != nil {
+			err = fmt.Errorf("metric设置有误:%v", err)
+			return err
+		}
 	}
 	return nil
 }
@@ -25,6 +34,30 @@ func (s *MqcServer) StopMetric() error {
 	return nil
 }
 
+//SetTracing 设置链路跟踪
+func (s *MqcServer) SetTracing(t *conf.Tracing) error {
+	s.StopTracing()
+	if t.Disable {
+		return nil
+	}
+	tracer, err := trace.New(s.conf.Name, t, func(err error) { s.Warnf("链路跟踪推送失败:%v", err) })
+	if err != nil {
+		err = fmt.Errorf("tracing设置有误:%v", err)
+		return err
+	}
+	s.conf.SetMetadata("__tracer_", tracer)
+	return nil
+}
+
+//StopTracing 关闭链路跟踪,导出剩余的span
+func (s *MqcServer) StopTracing() error {
+	if t, ok := s.conf.GetMetadata("__tracer_").(*trace.Tracer); ok {
+		s.conf.SetMetadata("__tracer_", nil)
+		return t.Close()
+	}
+	return nil
+}
+
 //SetQueues 设置监听队列
 func (s *MqcServer) SetQueues(proto string, raw string, queues []*conf.Queue) (err error) {
 	s.Processor, err = s.getProcessor(proto, raw, queues)
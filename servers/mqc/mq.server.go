@@ -51,6 +51,7 @@ func (s *MqcServer) Run() error {
 	}
 	go func(ch chan error) {
 		if err := s.Processor.Connect(); err != nil {
+			s.Processor.setConnError(err)
 			ch <- err
 		}
 	}(errChan)
@@ -65,10 +66,13 @@ func (s *MqcServer) Run() error {
 
 //Shutdown 关闭服务器
 func (s *MqcServer) Shutdown(timeout time.Duration) {
+	defer s.StopTracing()
 	if s.Processor != nil {
 		s.running = servers.ST_STOP
 		s.Processor.Close()
-		time.Sleep(time.Second)
+		if n := s.Processor.Drain(timeout); n > 0 {
+			s.Warnf("%s:%d条消息未在%v内处理完成", s.conf.Name, n, timeout)
+		}
 	}
 }
 
@@ -91,6 +95,14 @@ func (s *MqcServer) GetStatus() string {
 	return s.running
 }
 
+//Ready 检查消息消费是否就绪,暂停(非主节点)时视为就绪
+func (s *MqcServer) Ready() error {
+	if s.running == servers.ST_PAUSE || s.Processor == nil {
+		return nil
+	}
+	return s.Processor.Ready()
+}
+
 //Dynamic 动态注册或撤销消息队列
 func (s *MqcServer) Dynamic(engine servers.IRegistryEngine, c chan *conf.Queue) {
 	for {
@@ -40,9 +40,6 @@ func (w *MqcResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if !comparer.IsChanged() {
 		return false, nil
 	}
-	if comparer.IsVarChanged() {
-		return true, nil
-	}
 
 	if comparer.IsValueChanged("status", "sharding") {
 		return true, nil
@@ -62,7 +59,7 @@ func (w *MqcResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("queue未配置或配置有误:%v", err)
 	}
-	if ok := comparer.IsSubConfChanged("app"); ok {
+	if ok := comparer.IsSubConfChanged("app"); ok && !conf.IsAppWatched(cnf.GetServerType()) {
 		return ok, nil
 	}
 	return false, nil
@@ -94,6 +91,12 @@ func (w *MqcResponsiveServer) SetConf(restart bool, conf conf.IServerConf) (err
 	}
 	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "metric设置")
 
+	//设置链路跟踪
+	if ok, err = SetTracing(w.server, conf); err != nil {
+		return err
+	}
+	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "链路跟踪设置")
+
 	return nil
 }
 func getEnableName(b bool) string {
@@ -11,6 +11,7 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/engines"
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/servers/pkg/drain"
 )
 
 //MqcResponsiveServer rpc 响应式服务器
@@ -120,8 +121,7 @@ func (w *MqcResponsiveServer) Shutdown() {
 		close(w.closeChan)
 	})
 	w.unpublish()
-	timeout := w.currentConf.GetInt("timeout", 10)
-	w.server.Shutdown(time.Duration(timeout) * time.Second)
+	w.server.Shutdown(drain.GetTimeout(w.currentConf))
 	if w.engine != nil {
 		w.engine.Close()
 	}
@@ -137,6 +137,17 @@ func (w *MqcResponsiveServer) GetStatus() string {
 	return w.server.GetStatus()
 }
 
+//Ready 检查服务器是否就绪
+func (w *MqcResponsiveServer) Ready() error {
+	if w.done {
+		return servers.ErrServerClosed
+	}
+	if err := w.server.Ready(); err != nil {
+		return err
+	}
+	return w.engine.CheckHealth()
+}
+
 //GetServices 获取服务列表
 func (w *MqcResponsiveServer) GetServices() map[string][]string {
 	return w.engine.GetServices()
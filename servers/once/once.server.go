@@ -62,10 +62,14 @@ func (s *OnceServer) Run() error {
 }
 
 //Shutdown 关闭服务器
-func (s *OnceServer) Shutdown(time.Duration) {
+func (s *OnceServer) Shutdown(timeout time.Duration) {
+	defer s.StopTracing()
 	if s.Processor != nil {
 		s.running = servers.ST_STOP
 		s.Processor.Close()
+		if n := s.Processor.Drain(timeout); n > 0 {
+			s.Warnf("%s:%d个任务未在%v内执行完成", s.conf.Name, n, timeout)
+		}
 	}
 }
 
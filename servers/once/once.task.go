@@ -21,6 +21,8 @@ type iCronTask interface {
 	GetExecuted() int
 	AddExecuted()
 	NextTime(time.Time) time.Time
+	SetScheduledTime(time.Time)
+	GetScheduledTime() time.Time
 	GetHandler() interface{}
 	Enable() bool
 	SetDisable()
@@ -38,8 +40,9 @@ type cronTask struct {
 	form     map[string]interface{}
 	header   map[string]string
 	logger.ILogger
-	status int
-	result []byte
+	status    int
+	result    []byte
+	scheduled time.Time
 }
 
 func newCronTask(t *conf.Task) (r *cronTask, err error) {
@@ -91,6 +94,12 @@ func (m *cronTask) GetHandler() interface{} {
 func (m *cronTask) NextTime(t time.Time) time.Time {
 	return m.schedule.Next(t)
 }
+func (m *cronTask) SetScheduledTime(t time.Time) {
+	m.scheduled = t
+}
+func (m *cronTask) GetScheduledTime() time.Time {
+	return m.scheduled
+}
 func (m *cronTask) GetService() string {
 	return fmt.Sprintf("/%s", strings.TrimPrefix(m.Name, "/"))
 }
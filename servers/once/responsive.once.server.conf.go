@@ -40,9 +40,6 @@ func (w *OnceResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if !comparer.IsChanged() {
 		return false, nil
 	}
-	if comparer.IsVarChanged() {
-		return true, nil
-	}
 	if comparer.IsValueChanged("status", "sharding") {
 		return true, nil
 	}
@@ -53,7 +50,7 @@ func (w *OnceResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("task未配置或配置有误:%v", err)
 	}
-	if ok := comparer.IsSubConfChanged("app"); ok {
+	if ok := comparer.IsSubConfChanged("app"); ok && !conf.IsAppWatched(cnf.GetServerType()) {
 		return ok, nil
 	}
 	if ok := comparer.IsSubConfChanged("redis"); ok {
@@ -88,6 +85,12 @@ func (w *OnceResponsiveServer) SetConf(restart bool, conf conf.IServerConf) (err
 		return err
 	}
 	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "metric设置")
+
+	//设置链路跟踪
+	if ok, err = SetTracing(w.server, conf); err != nil {
+		return err
+	}
+	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "链路跟踪设置")
 	return nil
 }
 func getEnableName(b bool) string {
@@ -11,6 +11,7 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/engines"
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/servers/pkg/drain"
 )
 
 type OnceResponsiveServer struct {
@@ -120,7 +121,7 @@ func (w *OnceResponsiveServer) Shutdown() {
 		close(w.closeChan)
 	})
 	w.unpublish()
-	w.server.Shutdown(time.Second)
+	w.server.Shutdown(drain.GetTimeout(w.currentConf))
 	if w.engine != nil {
 		w.engine.Close()
 	}
@@ -136,6 +137,14 @@ func (w *OnceResponsiveServer) GetStatus() string {
 	return w.server.GetStatus()
 }
 
+//Ready 检查服务器是否就绪
+func (w *OnceResponsiveServer) Ready() error {
+	if w.done {
+		return servers.ErrServerClosed
+	}
+	return w.engine.CheckHealth()
+}
+
 //GetServices 获取服务列表
 func (w *OnceResponsiveServer) GetServices() map[string][]string {
 	return w.engine.GetServices()
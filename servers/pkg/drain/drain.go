@@ -0,0 +1,88 @@
+package drain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sereiner/parrot/conf"
+)
+
+//DefaultTimeout 默认等待处理中的请求完成的时长
+var DefaultTimeout = time.Second * 30
+
+//GetTimeout 获取服务器主配置中的drainTimeout(秒),未配置时使用timeout,均未配置时为DefaultTimeout
+func GetTimeout(cnf conf.IConf) time.Duration {
+	if n := cnf.GetInt("drainTimeout", 0); n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	if n := cnf.GetInt("timeout", 0); n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return DefaultTimeout
+}
+
+//Tracker 记录服务器正在处理的请求数,关闭服务器时等待请求处理完成
+type Tracker struct {
+	lock   sync.Mutex
+	count  int
+	closed bool
+	idle   chan struct{}
+}
+
+//NewTracker 构建请求跟踪器
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+//Begin 开始处理请求,跟踪器已关闭时返回false,请求不应再处理
+func (t *Tracker) Begin() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return false
+	}
+	t.count++
+	return true
+}
+
+//End 请求处理完成
+func (t *Tracker) End() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.count--
+	if t.count <= 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+//Count 获取正在处理的请求数
+func (t *Tracker) Count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.count
+}
+
+//Wait 等待正在处理的请求完成,超时后返回未完成的请求数;等待结束后跟踪器关闭,不再接收新请求
+func (t *Tracker) Wait(timeout time.Duration) int {
+	t.lock.Lock()
+	if t.count <= 0 {
+		t.closed = true
+		t.lock.Unlock()
+		return 0
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.lock.Unlock()
+
+	select {
+	case <-idle:
+	case <-time.After(timeout):
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.closed = true
+	return t.count
+}
@@ -0,0 +1,50 @@
+package drain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+)
+
+func TestTrackerWait(t *testing.T) {
+	tracker := NewTracker()
+	ut.Expect(t, tracker.Begin(), true)
+	ut.Expect(t, tracker.Begin(), true)
+	ut.Expect(t, tracker.Count(), 2)
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		tracker.End()
+		tracker.End()
+	}()
+	ut.Expect(t, tracker.Wait(time.Second), 0)
+	ut.Expect(t, tracker.Begin(), false)
+}
+
+func TestTrackerTimeout(t *testing.T) {
+	tracker := NewTracker()
+	ut.Expect(t, tracker.Begin(), true)
+	start := time.Now()
+	ut.Expect(t, tracker.Wait(time.Millisecond*50), 1)
+	ut.Expect(t, time.Since(start) >= time.Millisecond*50, true)
+	ut.Expect(t, tracker.Begin(), false)
+	tracker.End()
+	ut.Expect(t, tracker.Count(), 0)
+}
+
+func TestTrackerIdle(t *testing.T) {
+	tracker := NewTracker()
+	ut.Expect(t, tracker.Wait(time.Second), 0)
+	ut.Expect(t, tracker.Begin(), false)
+}
+
+func TestGetTimeout(t *testing.T) {
+	cnf, err := conf.NewJSONConf([]byte(`{"drainTimeout":5,"timeout":10}`), 0)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, GetTimeout(cnf), time.Second*5)
+	cnf, _ = conf.NewJSONConf([]byte(`{"timeout":10}`), 0)
+	ut.Expect(t, GetTimeout(cnf), time.Second*10)
+	cnf, _ = conf.NewJSONConf([]byte(`{}`), 0)
+	ut.Expect(t, GetTimeout(cnf), DefaultTimeout)
+}
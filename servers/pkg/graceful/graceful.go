@@ -0,0 +1,43 @@
+package graceful
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+//ReadyTimeout 等待新进程就绪的最长时间
+var ReadyTimeout = time.Second * 60
+
+//ErrNotReady 新进程未能在指定时间内就绪
+var ErrNotReady = errors.New("新进程未就绪")
+
+//Listen 获取指定地址的监听器,父进程已传入该地址的监听时直接复用,不重新绑定端口
+func Listen(addr string) (net.Listener, error) {
+	return current.listen(addr)
+}
+
+//Hold 保留当前所有监听端口,直到返回的函数被调用;用于服务器重启期间端口不被关闭
+func Hold() func() {
+	return current.hold()
+}
+
+//IsChild 当前进程是否由平滑重启启动
+func IsChild() bool {
+	return current.isChild()
+}
+
+//NotifyReady 通知父进程当前进程已就绪,并关闭未使用的继承监听
+func NotifyReady() error {
+	return current.notifyReady()
+}
+
+//IsHandedOff 监听端口是否已交给新进程;交接后新进程已发布相同地址的节点,当前进程关闭时不应删除
+func IsHandedOff() bool {
+	return current.isHandedOff()
+}
+
+//Restart 启动新进程并传入所有监听端口,新进程就绪后返回;返回错误时当前进程应继续提供服务
+func Restart(timeout time.Duration) error {
+	return current.restart(timeout)
+}
@@ -0,0 +1,273 @@
+// +build !windows
+
+package graceful
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//envListeners 新进程继承的监听地址,多个地址以逗号分隔,文件描述符从3开始依次对应
+	envListeners = "PARROT_LISTENERS"
+
+	//envReadyFD 新进程就绪后写入通知的文件描述符
+	envReadyFD = "PARROT_READY_FD"
+)
+
+//executable 进程启动时的执行文件路径,更新程序替换目录后仍指向新的执行文件
+var executable, _ = os.Executable()
+
+type entry struct {
+	reserve   *net.TCPListener
+	refs      int
+	inherited bool
+}
+
+type pool struct {
+	lock      sync.Mutex
+	entries   map[string]*entry
+	holds     int
+	readyFile *os.File
+	handedOff bool
+	once      sync.Once
+}
+
+var current = &pool{entries: make(map[string]*entry)}
+
+//inherit 读取父进程传入的监听
+func (p *pool) inherit() {
+	p.once.Do(func() {
+		addrs := os.Getenv(envListeners)
+		fd, _ := strconv.Atoi(os.Getenv(envReadyFD))
+		os.Unsetenv(envListeners)
+		os.Unsetenv(envReadyFD)
+		if fd > 0 {
+			p.readyFile = os.NewFile(uintptr(fd), "ready")
+		}
+		if addrs == "" {
+			return
+		}
+		for i, addr := range strings.Split(addrs, ",") {
+			f := os.NewFile(uintptr(3+i), addr)
+			l, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				continue
+			}
+			if tl, ok := l.(*net.TCPListener); ok {
+				p.entries[addr] = &entry{reserve: tl, inherited: true}
+				continue
+			}
+			l.Close()
+		}
+	})
+}
+
+func (p *pool) isChild() bool {
+	p.inherit()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.readyFile != nil
+}
+
+func (p *pool) listen(addr string) (net.Listener, error) {
+	p.inherit()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	e, ok := p.entries[addr]
+	if !ok {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		e = &entry{reserve: l.(*net.TCPListener)}
+		p.entries[addr] = e
+	}
+	f, err := e.reserve.File()
+	if err != nil {
+		p.release(addr, e)
+		return nil, err
+	}
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		p.release(addr, e)
+		return nil, err
+	}
+	e.refs++
+	e.inherited = false
+	return &listener{Listener: l, addr: addr, pool: p}, nil
+}
+
+func (p *pool) hold() func() {
+	p.lock.Lock()
+	p.holds++
+	p.lock.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			p.holds--
+			for addr, e := range p.entries {
+				p.release(addr, e)
+			}
+		})
+	}
+}
+
+//release 监听不再使用时关闭端口
+func (p *pool) release(addr string, e *entry) {
+	if e.refs > 0 || e.inherited || p.holds > 0 {
+		return
+	}
+	e.reserve.Close()
+	delete(p.entries, addr)
+}
+
+func (p *pool) done(addr string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if e, ok := p.entries[addr]; ok {
+		e.refs--
+		p.release(addr, e)
+	}
+}
+
+func (p *pool) notifyReady() error {
+	p.inherit()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for addr, e := range p.entries {
+		if e.inherited {
+			e.inherited = false
+			p.release(addr, e)
+		}
+	}
+	if p.readyFile == nil {
+		return nil
+	}
+	defer func() {
+		p.readyFile.Close()
+		p.readyFile = nil
+	}()
+	_, err := p.readyFile.Write([]byte("ready"))
+	return err
+}
+
+func (p *pool) restart(timeout time.Duration) error {
+	p.inherit()
+	if executable == "" {
+		return fmt.Errorf("无法获取执行文件路径")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	p.lock.Lock()
+	addrs := make([]string, 0, len(p.entries))
+	files := make([]*os.File, 0, len(p.entries)+1)
+	for addr, e := range p.entries {
+		f, err := e.reserve.File()
+		if err != nil {
+			p.lock.Unlock()
+			closeFiles(append(files, w))
+			return fmt.Errorf("无法获取监听%s:%v", addr, err)
+		}
+		addrs = append(addrs, addr)
+		files = append(files, f)
+	}
+	p.lock.Unlock()
+
+	files = append(files, w)
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(environ(),
+		fmt.Sprintf("%s=%s", envListeners, strings.Join(addrs, ",")),
+		fmt.Sprintf("%s=%d", envReadyFD, 3+len(addrs)))
+	err = cmd.Start()
+	closeFiles(files)
+	if err != nil {
+		return fmt.Errorf("新进程启动失败:%v", err)
+	}
+
+	//新进程退出时管道关闭,读取立即返回
+	ready := make(chan error, 1)
+	go func() {
+		buff := make([]byte, 5)
+		n, err := r.Read(buff)
+		if n > 0 {
+			ready <- nil
+			return
+		}
+		ready <- fmt.Errorf("新进程已退出:%v", err)
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		err = ErrNotReady
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		go cmd.Wait()
+		return err
+	}
+	cmd.Process.Release()
+	p.lock.Lock()
+	p.handedOff = true
+	p.lock.Unlock()
+	return nil
+}
+
+func (p *pool) isHandedOff() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.handedOff
+}
+
+//environ 获取当前环境变量,不包括平滑重启使用的变量
+func environ() []string {
+	env := os.Environ()
+	list := make([]string, 0, len(env))
+	for _, v := range env {
+		if strings.HasPrefix(v, envListeners+"=") || strings.HasPrefix(v, envReadyFD+"=") {
+			continue
+		}
+		list = append(list, v)
+	}
+	return list
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+//listener 关闭时仅关闭当前监听,端口由pool保留
+type listener struct {
+	net.Listener
+	addr string
+	pool *pool
+	once sync.Once
+}
+
+func (l *listener) Close() (err error) {
+	l.once.Do(func() {
+		err = l.Listener.Close()
+		l.pool.done(l.addr)
+	})
+	return
+}
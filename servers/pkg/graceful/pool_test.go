@@ -0,0 +1,92 @@
+// +build !windows
+
+package graceful
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sereiner/library/ut"
+)
+
+func newPool() *pool {
+	p := &pool{entries: make(map[string]*entry)}
+	p.once.Do(func() {})
+	return p
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	ut.Expect(t, err, nil)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func accept(t *testing.T, l net.Listener, addr string) {
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	ut.Expect(t, err, nil)
+	conn.Close()
+}
+
+func TestListenReuse(t *testing.T) {
+	p := newPool()
+	addr := freeAddr(t)
+	l1, err := p.listen(addr)
+	ut.Expect(t, err, nil)
+	l1.Close()
+	ut.Expect(t, len(p.entries), 0)
+
+	l1, err = p.listen(addr)
+	ut.Expect(t, err, nil)
+	l2, err := p.listen(addr)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, p.entries[addr].refs, 2)
+	l1.Close()
+	l1.Close()
+	ut.Expect(t, p.entries[addr].refs, 1)
+	accept(t, l2, addr)
+	l2.Close()
+	ut.Expect(t, len(p.entries), 0)
+}
+
+func TestHold(t *testing.T) {
+	p := newPool()
+	addr := freeAddr(t)
+	l, err := p.listen(addr)
+	ut.Expect(t, err, nil)
+
+	release := p.hold()
+	l.Close()
+	ut.Expect(t, len(p.entries), 1)
+
+	//端口保留期间连接在队列中等待
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	ut.Expect(t, err, nil)
+	defer conn.Close()
+
+	l, err = p.listen(addr)
+	ut.Expect(t, err, nil)
+	release()
+	release()
+	ut.Expect(t, p.entries[addr].refs, 1)
+	c, err := l.Accept()
+	ut.Expect(t, err, nil)
+	c.Close()
+	l.Close()
+	ut.Expect(t, len(p.entries), 0)
+}
+
+func TestRestartFailed(t *testing.T) {
+	p := newPool()
+	exec := executable
+	executable = ""
+	defer func() { executable = exec }()
+	ut.Refute(t, p.restart(time.Second), nil)
+	ut.Expect(t, p.isHandedOff(), false)
+}
@@ -0,0 +1,36 @@
+package graceful
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+//pool windows下无法将监听传递给新进程,直接绑定端口
+type pool struct{}
+
+var current = &pool{}
+
+func (p *pool) isChild() bool {
+	return false
+}
+
+func (p *pool) listen(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
+
+func (p *pool) hold() func() {
+	return func() {}
+}
+
+func (p *pool) notifyReady() error {
+	return nil
+}
+
+func (p *pool) isHandedOff() bool {
+	return false
+}
+
+func (p *pool) restart(timeout time.Duration) error {
+	return fmt.Errorf("windows不支持平滑重启")
+}
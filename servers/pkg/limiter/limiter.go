@@ -0,0 +1,201 @@
+package limiter
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sereiner/library/redis"
+	"github.com/sereiner/parrot/conf"
+)
+
+//IStore 令牌桶存储
+type IStore interface {
+	Take(key string, rate float64, burst int) (allow bool, retryAfter time.Duration, err error)
+	Close() error
+}
+
+//Limiter 按路由与客户端限流
+type Limiter struct {
+	conf  *conf.Limiter
+	store IStore
+}
+
+//New 构建限流组件,配置了cache时使用var/cache中的redis共享令牌桶
+func New(c *conf.Limiter, v conf.IVarConf) (*Limiter, error) {
+	if c.Cache == "" {
+		return &Limiter{conf: c, store: NewLocalStore()}, nil
+	}
+	cacheConf, err := v.GetVarConf("cache", c.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("var/cache/%s:%v", c.Cache, err)
+	}
+	if proto := cacheConf.GetString("proto"); proto != "redis" {
+		return nil, fmt.Errorf("var/cache/%s:不支持的缓存类型%s,仅支持redis", c.Cache, proto)
+	}
+	client, err := redis.NewClientByJSON(string(cacheConf.GetRaw()))
+	if err != nil {
+		return nil, fmt.Errorf("var/cache/%s:%v", c.Cache, err)
+	}
+	return &Limiter{conf: c, store: NewRedisStore(client, "parrot:limiter:")}, nil
+}
+
+//Allow 检查请求是否允许通过,getKey根据规则获取客户端标识,标识为空的请求不限流;
+//存储出错时放行请求并返回错误
+func (l *Limiter) Allow(url string, getKey func(r *conf.LimitRule) string) (allow bool, retryAfter time.Duration, err error) {
+	rule := l.conf.GetRule(url)
+	if rule == nil {
+		return true, 0, nil
+	}
+	key := getKey(rule)
+	if key == "" {
+		return true, 0, nil
+	}
+	allow, retryAfter, err = l.store.Take(fmt.Sprintf("%s|%s|%s", rule.URL, rule.By, key), rule.Rate, rule.GetBurst())
+	if err != nil {
+		return true, 0, err
+	}
+	return allow, retryAfter, nil
+}
+
+//GetClientIP 获取客户端ip,默认使用连接的远端地址;远端为受信任的代理时,
+//从X-Forwarded-For由右向左取第一个非受信任代理的地址
+func (l *Limiter) GetClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !l.conf.IsTrustedProxy(ip) {
+		return ip
+	}
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		v := strings.TrimSpace(forwarded[i])
+		if v == "" {
+			continue
+		}
+		if !l.conf.IsTrustedProxy(v) {
+			return v
+		}
+		ip = v
+	}
+	return ip
+}
+
+//Close 关闭限流组件
+func (l *Limiter) Close() error {
+	return l.store.Close()
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+//LocalStore 本地令牌桶
+type LocalStore struct {
+	buckets map[string]*bucket
+	lock    sync.Mutex
+	swept   time.Time
+}
+
+//NewLocalStore 构建本地令牌桶存储
+func NewLocalStore() *LocalStore {
+	return &LocalStore{buckets: make(map[string]*bucket), swept: time.Now()}
+}
+
+//Take 获取一个令牌,无可用令牌时返回需等待的时长
+func (s *LocalStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+}
+
+//sweep 每分钟清理一次已填满的令牌桶
+func (s *LocalStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+	for k, b := range s.buckets {
+		if now.Sub(b.last) > time.Minute {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+//Close 关闭存储
+func (s *LocalStore) Close() error {
+	return nil
+}
+
+//takeScript 令牌桶脚本,返回{是否允许,需等待的毫秒数}
+var takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local v = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(v[1]) or burst
+local ts = tonumber(v[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allow = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allow = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allow, wait}
+`
+
+//RedisStore 基于redis的共享令牌桶
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+//NewRedisStore 构建redis令牌桶存储
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+//Take 获取一个令牌,无可用令牌时返回需等待的时长
+func (s *RedisStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	r, err := s.client.Eval(takeScript, []string{s.prefix + key}, rate, burst, now).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	values, ok := r.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("限流脚本返回值有误:%v", r)
+	}
+	allow, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	return allow == 1, time.Duration(wait) * time.Millisecond, nil
+}
+
+//Close 关闭redis连接
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,95 @@
+package limiter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+)
+
+func TestLocalStore(t *testing.T) {
+	s := NewLocalStore()
+	for i := 0; i < 3; i++ {
+		allow, _, err := s.Take("a", 1, 3)
+		ut.Expect(t, err, nil)
+		ut.Expect(t, allow, true)
+	}
+	allow, retry, err := s.Take("a", 1, 3)
+	ut.Expect(t, err, nil)
+	ut.Expect(t, allow, false)
+	ut.Expect(t, retry > 0, true)
+
+	allow, _, _ = s.Take("b", 1, 3)
+	ut.Expect(t, allow, true)
+}
+
+func TestRuleMatch(t *testing.T) {
+	ut.Expect(t, conf.NewLimitRule("*", "ip", 1, 0).Match("/order/pay"), true)
+	ut.Expect(t, conf.NewLimitRule("/order/pay", "ip", 1, 0).Match("/order/pay"), true)
+	ut.Expect(t, conf.NewLimitRule("/order/*", "ip", 1, 0).Match("/order/pay"), true)
+	ut.Expect(t, conf.NewLimitRule("/order/*", "ip", 1, 0).Match("/order/pay/query"), false)
+	ut.Expect(t, conf.NewLimitRule("/order/**", "ip", 1, 0).Match("/order/pay/query"), true)
+	ut.Expect(t, conf.NewLimitRule("/order/*", "ip", 1, 0).Match("/user/pay"), false)
+	ut.Expect(t, conf.NewLimitRule("/order", "ip", 0.5, 0).GetBurst(), 1)
+}
+
+func TestAllow(t *testing.T) {
+	c := conf.NewLimiter(
+		conf.NewLimitRule("/order/pay", "header", 1, 1).WithName("x-uid"),
+		conf.NewLimitRule("/order/**", "ip", 1, 2),
+	)
+	ut.Expect(t, c.Validate(), nil)
+	l, err := New(c, nil)
+	ut.Expect(t, err, nil)
+	defer l.Close()
+
+	byKey := func(k string) func(r *conf.LimitRule) string {
+		return func(r *conf.LimitRule) string { return k }
+	}
+	allow, _, _ := l.Allow("/order/pay", byKey("u1"))
+	ut.Expect(t, allow, true)
+	allow, retry, _ := l.Allow("/order/pay", byKey("u1"))
+	ut.Expect(t, allow, false)
+	ut.Expect(t, retry > 0, true)
+	allow, _, _ = l.Allow("/order/pay", byKey("u2"))
+	ut.Expect(t, allow, true)
+	allow, _, _ = l.Allow("/order/pay", byKey(""))
+	ut.Expect(t, allow, true)
+
+	allow, _, _ = l.Allow("/order/query", byKey("127.0.0.1"))
+	ut.Expect(t, allow, true)
+	allow, _, _ = l.Allow("/order/query", byKey("127.0.0.1"))
+	ut.Expect(t, allow, true)
+	allow, _, _ = l.Allow("/order/query", byKey("127.0.0.1"))
+	ut.Expect(t, allow, false)
+
+	allow, _, _ = l.Allow("/user/query", byKey("127.0.0.1"))
+	ut.Expect(t, allow, true)
+
+	ut.Expect(t, conf.NewLimiter(conf.NewLimitRule("/order", "jwt", 1, 0)).Validate() != nil, true)
+	ut.Expect(t, conf.NewLimiter(conf.NewLimitRule("/order", "ip", 0, 0)).Validate() != nil, true)
+}
+
+func TestGetClientIP(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/order/pay", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+
+	//未配置受信任代理时忽略X-Forwarded-For
+	l, _ := New(conf.NewLimiter(conf.NewLimitRule("*", "ip", 1, 0)), nil)
+	ut.Expect(t, l.GetClientIP(r), "10.0.0.1")
+
+	c := conf.NewLimiter(conf.NewLimitRule("*", "ip", 1, 0)).WithTrustedProxies("10.0.0.0/8", "2.2.2.2")
+	ut.Expect(t, c.Validate(), nil)
+	l, _ = New(c, nil)
+	ut.Expect(t, l.GetClientIP(r), "1.1.1.1")
+
+	r.Header.Set("X-Forwarded-For", "1.1.1.1, 3.3.3.3")
+	ut.Expect(t, l.GetClientIP(r), "3.3.3.3")
+
+	r.RemoteAddr = "4.4.4.4:5000"
+	ut.Expect(t, l.GetClientIP(r), "4.4.4.4")
+
+	ut.Expect(t, conf.NewLimiter(conf.NewLimitRule("*", "ip", 1, 0)).WithTrustedProxies("x").Validate() != nil, true)
+}
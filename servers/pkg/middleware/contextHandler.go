@@ -15,6 +15,7 @@ import (
 	"github.com/sereiner/parrot/context"
 	"github.com/sereiner/parrot/servers"
 	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+	"github.com/sereiner/parrot/trace"
 )
 
 func getUUID(c *dispatcher.Context) string {
@@ -64,6 +65,18 @@ func setCTX(c *dispatcher.Context, r *context.Context) {
 func getTrace(cnf *conf.MetadataConf) bool {
 	return cnf.GetMetadata("show-trace").(bool)
 }
+func getTracer(cnf *conf.MetadataConf) *trace.Tracer {
+	t, _ := cnf.GetMetadata("__tracer_").(*trace.Tracer)
+	return t
+}
+func setSpan(c *dispatcher.Context, s *trace.Span) {
+	c.Set("__trace_span_", s)
+}
+func getSpan(c *dispatcher.Context) *trace.Span {
+	s, _ := c.Get("__trace_span_")
+	span, _ := s.(*trace.Span)
+	return span
+}
 func getCTX(c *dispatcher.Context) *context.Context {
 	result, _ := c.Get("__context_")
 	if result == nil {
@@ -140,6 +153,7 @@ func makeExtData(c *dispatcher.Context, ext map[string]interface{}) map[string]i
 	input["__method_"] = strings.ToLower(c.Request.GetMethod())
 	input["__header_"] = c.Request.GetHeader()
 	input["__jwt_"] = getJWTRaw(c)
+	input["__trace_span_"] = getSpan(c)
 	input["__func_http_request_"] = c.Request
 	input["__func_http_response_"] = c.Writer
 	input["__binding_"] = func(obj interface{}) error {
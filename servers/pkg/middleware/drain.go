@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+)
+
+//Drain 记录正在处理的请求,服务器关闭后拒绝新请求
+func Drain(t *drain.Tracker) dispatcher.HandlerFunc {
+	return func(ctx *dispatcher.Context) {
+		if !t.Begin() {
+			ctx.AbortWithStatus(503)
+			return
+		}
+		defer t.End()
+		ctx.Next()
+	}
+}
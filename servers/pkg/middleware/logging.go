@@ -1,14 +1,22 @@
 package middleware
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+	"github.com/sereiner/parrot/trace"
 )
 
+//spanKinds 服务器类型对应的span类型
+var spanKinds = map[string]trace.SpanKind{
+	"rpc": trace.KindServer,
+	"mqc": trace.KindConsumer,
+}
+
 //Logging 记录日志
 func Logging(conf *conf.MetadataConf) dispatcher.HandlerFunc {
 	return func(ctx *dispatcher.Context) {
@@ -20,10 +28,18 @@ func Logging(conf *conf.MetadataConf) dispatcher.HandlerFunc {
 		log := logger.GetSession(conf.Name, uuid, "biz", strings.Replace(strings.Trim(ctx.Request.GetService(), "/"), "/", "_", -1))
 		log.Info(conf.Type+".request:", conf.Name, ctx.Request.GetMethod(), p, "from", ctx.ClientIP())
 		setLogger(ctx, log)
+		span := getTracer(conf).Start(conf.Type+" "+p, getSpanKind(conf.Type), ctx.Request.GetHeader()[trace.HeaderName])
+		span.SetAttribute("server.name", conf.Name).SetAttribute("service", p).SetAttribute("method", ctx.Request.GetMethod())
+		setSpan(ctx, span)
+		defer span.End()
 		ctx.Next()
 
 		v, _ := getResponseRaw(ctx)
 		statusCode := ctx.Writer.Status()
+		span.SetAttribute("status", statusCode)
+		if statusCode >= 500 {
+			span.SetError(fmt.Errorf("status:%d", statusCode))
+		}
 		if statusCode >= 200 && statusCode < 400 {
 			log.Info(conf.Type+".response:", conf.Name, ctx.Request.GetMethod(), p, statusCode, time.Since(start), v)
 		} else {
@@ -31,3 +47,10 @@ func Logging(conf *conf.MetadataConf) dispatcher.HandlerFunc {
 		}
 	}
 }
+
+func getSpanKind(tp string) trace.SpanKind {
+	if k, ok := spanKinds[tp]; ok {
+		return k
+	}
+	return trace.KindInternal
+}
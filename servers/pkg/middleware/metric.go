@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sereiner/library/xsync"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/sereiner/library/net"
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+	"github.com/sereiner/parrot/servers/pkg/prometheus"
 	"github.com/sereiner/parrot/servers/pkg/timer"
 )
 
@@ -37,6 +39,8 @@ type Metric struct {
 	timer           *timer.Timer
 	done            bool
 	closeChan       chan struct{}
+	prometheus      *prometheus.Server
+	prometheusPath  atomic.Value
 }
 
 //NewMetric new metric
@@ -65,6 +69,11 @@ func (m *Metric) Stop() {
 	if m.timer != nil {
 		m.timer.Close()
 	}
+	if m.prometheus != nil {
+		m.prometheus.Close()
+		m.prometheus = nil
+	}
+	m.prometheusPath.Store("")
 }
 
 //Restart restart metric
@@ -94,6 +103,18 @@ func (m *Metric) Restart(host string, dataBase string, userName string, password
 	m.timer.Start()
 	return nil
 }
+
+//SetPrometheus 在address上提供prometheus格式的metrics接口
+func (m *Metric) SetPrometheus(path string, address string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if address == "" {
+		return fmt.Errorf("prometheus未配置address")
+	}
+	m.prometheus, err = prometheus.Start(address, path, m.currentRegistry)
+	return err
+}
+
 func (m *Metric) collectSys() {
 	if !m.ticket.Wait() {
 		return
@@ -104,8 +125,14 @@ func (m *Metric) collectSys() {
 	go m.loopNetConnCount()
 }
 
-//Handle 处理请求
+//Handle 处理请求,mqc按队列,cron,once按任务统计,其它服务器按请求统计
 func (m *Metric) Handle() dispatcher.HandlerFunc {
+	switch m.conf.Type {
+	case "mqc":
+		return m.handleQueue
+	case "cron", "once":
+		return m.handleTask
+	}
 	return func(ctx *dispatcher.Context) {
 		url := ctx.Request.GetService()
 
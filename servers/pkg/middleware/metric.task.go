@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/sereiner/library/metrics"
+	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+)
+
+//IQueueRequest 消息队列请求
+type IQueueRequest interface {
+	GetQueue() string
+}
+
+//IScheduledRequest 定时任务请求,用于统计计划执行时间与实际执行时间的偏差
+type IScheduledRequest interface {
+	GetScheduledTime() time.Time
+}
+
+//handleQueue 按队列统计消费数,失败数,处理时长与并发数
+func (m *Metric) handleQueue(ctx *dispatcher.Context) {
+	service := ctx.Request.GetService()
+	queue := service
+	if r, ok := ctx.Request.(IQueueRequest); ok {
+		queue = r.GetQueue()
+	}
+	tags := []string{"server", m.conf.Name, "host", m.ip, "queue", queue, "service", service}
+	m.track(ctx, m.conf.Type+".server.queue", tags, func(failed bool) {
+		metrics.GetOrRegisterMeter(metrics.MakeName(m.conf.Type+".server.queue.consumed", metrics.METER, tags...), m.currentRegistry).Mark(1)
+		if failed {
+			metrics.GetOrRegisterMeter(metrics.MakeName(m.conf.Type+".server.queue.failed", metrics.METER, tags...), m.currentRegistry).Mark(1)
+		}
+	})
+}
+
+//handleTask 按任务统计执行数,失败数,执行时长,最后成功时间与计划执行时间偏差
+func (m *Metric) handleTask(ctx *dispatcher.Context) {
+	tags := []string{"server", m.conf.Name, "host", m.ip, "task", ctx.Request.GetService()}
+	if r, ok := ctx.Request.(IScheduledRequest); ok && !r.GetScheduledTime().IsZero() {
+		skew := time.Since(r.GetScheduledTime()) / time.Millisecond
+		metrics.GetOrRegisterHistogram(metrics.MakeName(m.conf.Type+".server.task.skew", metrics.HISTOGRAM, tags...),
+			m.currentRegistry, metrics.NewExpDecaySample(1028, 0.015)).Update(int64(skew)) //偏差毫秒数
+	}
+	m.track(ctx, m.conf.Type+".server.task", tags, func(failed bool) {
+		metrics.GetOrRegisterMeter(metrics.MakeName(m.conf.Type+".server.task.executed", metrics.METER, tags...), m.currentRegistry).Mark(1)
+		if failed {
+			metrics.GetOrRegisterMeter(metrics.MakeName(m.conf.Type+".server.task.failed", metrics.METER, tags...), m.currentRegistry).Mark(1)
+			return
+		}
+		metrics.GetOrRegisterGauge(metrics.MakeName(m.conf.Type+".server.task.success", metrics.GAUGE, tags...), m.currentRegistry).Update(time.Now().Unix())
+	})
+}
+
+//track 统计并发数与处理时长,处理完成(包括panic)后回调done
+func (m *Metric) track(ctx *dispatcher.Context, name string, tags []string, done func(failed bool)) {
+	counter := metrics.GetOrRegisterCounter(metrics.MakeName(name, metrics.WORKING, tags...), m.currentRegistry)
+	counter.Inc(1)
+	start := time.Now()
+	defer func() {
+		counter.Dec(1)
+		metrics.GetOrRegisterTimer(metrics.MakeName(name, metrics.TIMER, tags...), m.currentRegistry).UpdateSince(start)
+		if err := recover(); err != nil {
+			done(true)
+			panic(err)
+		}
+		done(ctx.Writer.Status() >= 400 || len(ctx.Errors) > 0)
+	}()
+	ctx.Next()
+}
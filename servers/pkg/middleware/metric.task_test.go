@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sereiner/library/metrics"
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/servers/pkg/dispatcher"
+)
+
+type testRequest struct {
+	service   string
+	queue     string
+	scheduled time.Time
+}
+
+func (r *testRequest) GetService() string              { return r.service }
+func (r *testRequest) GetMethod() string               { return "GET" }
+func (r *testRequest) GetForm() map[string]interface{} { return map[string]interface{}{} }
+func (r *testRequest) GetHeader() map[string]string    { return map[string]string{} }
+func (r *testRequest) GetQueue() string                { return r.queue }
+func (r *testRequest) GetScheduledTime() time.Time     { return r.scheduled }
+
+func newTestEngine(m *Metric) *dispatcher.Dispatcher {
+	engine := dispatcher.New()
+	engine.Use(m.Handle())
+	engine.Handle("GET", "/order/pay", func(ctx *dispatcher.Context) { ctx.Writer.WriteHeader(200) })
+	engine.Handle("GET", "/order/fail", func(ctx *dispatcher.Context) { ctx.AbortWithError(500, errors.New("err")) })
+	return engine
+}
+
+func TestMetricQueue(t *testing.T) {
+	m := NewMetric(&conf.MetadataConf{Name: "t", Type: "mqc"})
+	engine := newTestEngine(m)
+	engine.HandleRequest(&testRequest{service: "/order/pay", queue: "order:pay"})
+	engine.HandleRequest(&testRequest{service: "/order/pay", queue: "order:pay"})
+	engine.HandleRequest(&testRequest{service: "/order/fail", queue: "order:fail"})
+
+	tags := []string{"server", "t", "host", m.ip, "queue", "order:pay", "service", "/order/pay"}
+	consumed := m.currentRegistry.Get(metrics.MakeName("mqc.server.queue.consumed", metrics.METER, tags...)).(metrics.Meter)
+	ut.Expect(t, consumed.Count(), int64(2))
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("mqc.server.queue.failed", metrics.METER, tags...)), nil)
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("mqc.server.queue", metrics.WORKING, tags...)).(metrics.Counter).Count(), int64(0))
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("mqc.server.queue", metrics.TIMER, tags...)).(metrics.Timer).Count(), int64(2))
+
+	tags = []string{"server", "t", "host", m.ip, "queue", "order:fail", "service", "/order/fail"}
+	failed := m.currentRegistry.Get(metrics.MakeName("mqc.server.queue.failed", metrics.METER, tags...)).(metrics.Meter)
+	ut.Expect(t, failed.Count(), int64(1))
+}
+
+func TestMetricTask(t *testing.T) {
+	m := NewMetric(&conf.MetadataConf{Name: "t", Type: "cron"})
+	engine := newTestEngine(m)
+	engine.HandleRequest(&testRequest{service: "/order/pay", scheduled: time.Now().Add(-time.Second)})
+	engine.HandleRequest(&testRequest{service: "/order/fail"})
+
+	tags := []string{"server", "t", "host", m.ip, "task", "/order/pay"}
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("cron.server.task.executed", metrics.METER, tags...)).(metrics.Meter).Count(), int64(1))
+	success := m.currentRegistry.Get(metrics.MakeName("cron.server.task.success", metrics.GAUGE, tags...)).(metrics.Gauge)
+	ut.Expect(t, success.Value() >= time.Now().Add(-time.Minute).Unix(), true)
+	skew := m.currentRegistry.Get(metrics.MakeName("cron.server.task.skew", metrics.HISTOGRAM, tags...)).(metrics.Histogram)
+	ut.Expect(t, skew.Min() >= 1000, true)
+
+	tags = []string{"server", "t", "host", m.ip, "task", "/order/fail"}
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("cron.server.task.failed", metrics.METER, tags...)).(metrics.Meter).Count(), int64(1))
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("cron.server.task.success", metrics.GAUGE, tags...)), nil)
+	ut.Expect(t, m.currentRegistry.Get(metrics.MakeName("cron.server.task.skew", metrics.HISTOGRAM, tags...)), nil)
+}
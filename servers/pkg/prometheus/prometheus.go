@@ -0,0 +1,213 @@
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sereiner/library/metrics"
+)
+
+//ContentType prometheus文本格式
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+//DefaultPath 默认的metrics路径
+const DefaultPath = "/metrics"
+
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+type sample struct {
+	suffix string
+	labels map[string]string
+	value  float64
+}
+
+type family struct {
+	name    string
+	tp      string
+	samples []*sample
+}
+
+//Write 将注册表中的指标按prometheus文本格式输出,指标名称由metrics.MakeName构建,并附加go运行时指标
+func Write(w io.Writer, r metrics.Registry) error {
+	families := make(map[string]*family)
+	add := func(name string, tp string, s *sample) {
+		f, ok := families[name]
+		if !ok {
+			f = &family{name: name, tp: tp}
+			families[name] = f
+		}
+		f.samples = append(f.samples, s)
+	}
+	r.Each(func(key string, obj interface{}) {
+		name, labels := splitName(key)
+		switch m := obj.(type) {
+		case metrics.IQPS:
+			m.Mark(0)
+			add(name+"_m1", "gauge", &sample{labels: labels, value: float64(m.M1())})
+			add(name+"_m5", "gauge", &sample{labels: labels, value: float64(m.M5())})
+			add(name+"_m15", "gauge", &sample{labels: labels, value: float64(m.M15())})
+		case metrics.Counter:
+			add(name, "gauge", &sample{labels: labels, value: float64(m.Count())})
+		case metrics.Gauge:
+			add(name, "gauge", &sample{labels: labels, value: float64(m.Value())})
+		case metrics.GaugeFloat64:
+			add(name, "gauge", &sample{labels: labels, value: m.Value()})
+		case metrics.Meter:
+			ms := m.Snapshot()
+			add(name+"_total", "counter", &sample{labels: labels, value: float64(ms.Count())})
+			add(name+"_m1", "gauge", &sample{labels: labels, value: ms.Rate1()})
+			add(name+"_m5", "gauge", &sample{labels: labels, value: ms.Rate5()})
+			add(name+"_m15", "gauge", &sample{labels: labels, value: ms.Rate15()})
+		case metrics.Timer:
+			//计时器单位为纳秒,转换为秒
+			ms := m.Snapshot()
+			name = name + "_seconds"
+			for i, v := range ms.Percentiles(quantiles) {
+				add(name, "summary", &sample{labels: withLabel(labels, "quantile", fmt.Sprint(quantiles[i])), value: v / float64(time.Second)})
+			}
+			add(name, "summary", &sample{suffix: "_sum", labels: labels, value: float64(ms.Sum()) / float64(time.Second)})
+			add(name, "summary", &sample{suffix: "_count", labels: labels, value: float64(ms.Count())})
+		case metrics.Histogram:
+			ms := m.Snapshot()
+			for i, v := range ms.Percentiles(quantiles) {
+				add(name, "summary", &sample{labels: withLabel(labels, "quantile", fmt.Sprint(quantiles[i])), value: v})
+			}
+			add(name, "summary", &sample{suffix: "_sum", labels: labels, value: float64(ms.Sum())})
+			add(name, "summary", &sample{suffix: "_count", labels: labels, value: float64(ms.Count())})
+		}
+	})
+	writeRuntime(add)
+
+	names := make([]string, 0, len(families))
+	for k := range families {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.tp)
+		for _, s := range f.samples {
+			fmt.Fprintf(bw, "%s%s%s %s\n", f.name, s.suffix, formatLabels(s.labels), formatValue(s.value))
+		}
+	}
+	return bw.Flush()
+}
+
+//writeRuntime go运行时指标
+func writeRuntime(add func(name string, tp string, s *sample)) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	add("go_goroutines", "gauge", &sample{value: float64(runtime.NumGoroutine())})
+	add("go_memstats_alloc_bytes", "gauge", &sample{value: float64(ms.Alloc)})
+	add("go_memstats_sys_bytes", "gauge", &sample{value: float64(ms.Sys)})
+	add("go_memstats_heap_inuse_bytes", "gauge", &sample{value: float64(ms.HeapInuse)})
+	add("go_memstats_heap_objects", "gauge", &sample{value: float64(ms.HeapObjects)})
+	add("go_memstats_mallocs_total", "counter", &sample{value: float64(ms.Mallocs)})
+	add("go_memstats_frees_total", "counter", &sample{value: float64(ms.Frees)})
+	add("go_gc_cycles_total", "counter", &sample{value: float64(ms.NumGC)})
+	add("go_gc_pause_seconds_total", "counter", &sample{value: float64(ms.PauseTotalNs) / float64(time.Second)})
+}
+
+//splitName 拆分metrics.MakeName构建的名称,如 api.server.request.qps:>name:>x:>url:>/order
+func splitName(key string) (string, map[string]string) {
+	sections := strings.Split(key, ":>")
+	labels := make(map[string]string)
+	for i := 1; i+1 < len(sections); i += 2 {
+		labels[sanitize(sections[i])] = sections[i+1]
+	}
+	return sanitize(sections[0]), labels
+}
+
+func sanitize(name string) string {
+	name = invalidChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func withLabel(labels map[string]string, k string, v string) map[string]string {
+	nlabels := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		nlabels[key] = value
+	}
+	nlabels[k] = v
+	return nlabels
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]string, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, fmt.Sprintf(`%s="%s"`, k, escape(labels[k])))
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+func escape(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, "\n", `\n`, -1)
+	return strings.Replace(v, `"`, `\"`, -1)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+//Handler 输出注册表指标的http处理程序
+func Handler(r metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		Write(w, r)
+	})
+}
+
+//Server 独立端口的metrics服务
+type Server struct {
+	server *http.Server
+}
+
+//Start 在指定地址启动metrics服务
+func Start(address string, path string, r metrics.Registry) (*Server, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("metrics服务启动失败:%v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, Handler(r))
+	s := &Server{server: &http.Server{Handler: mux, ReadHeaderTimeout: time.Second * 5}}
+	go s.server.Serve(l)
+	return s, nil
+}
+
+//Close 关闭metrics服务
+func (s *Server) Close() error {
+	return s.server.Close()
+}
@@ -0,0 +1,30 @@
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sereiner/library/metrics"
+	"github.com/sereiner/library/ut"
+)
+
+func TestWrite(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterQPS(metrics.MakeName("api.server.request", metrics.QPS, "server", "t", "url", "/order"), r).Mark(1)
+	metrics.GetOrRegisterTimer(metrics.MakeName("api.server.request", metrics.TIMER, "server", "t", "url", "/order"), r).Update(time.Millisecond * 500)
+	metrics.GetOrRegisterMeter(metrics.MakeName("api.server.response", metrics.METER, "server", "t", "status", "200"), r).Mark(2)
+
+	buff := bytes.NewBuffer(nil)
+	err := Write(buff, r)
+	ut.Expect(t, err, nil)
+	text := buff.String()
+	ut.Expect(t, strings.Contains(text, "# TYPE api_server_request_qps_m1 gauge\n"), true)
+	ut.Expect(t, strings.Contains(text, `api_server_request_qps_m1{server="t",url="/order"} `), true)
+	ut.Expect(t, strings.Contains(text, "# TYPE api_server_request_timer_seconds summary\n"), true)
+	ut.Expect(t, strings.Contains(text, `api_server_request_timer_seconds_sum{server="t",url="/order"} 0.5`), true)
+	ut.Expect(t, strings.Contains(text, `api_server_request_timer_seconds_count{server="t",url="/order"} 1`), true)
+	ut.Expect(t, strings.Contains(text, `api_server_response_meter_total{server="t",status="200"} 2`), true)
+	ut.Expect(t, strings.Contains(text, "# TYPE go_goroutines gauge\n"), true)
+}
@@ -24,7 +24,7 @@ func SetMetric(set ISetMetric, cnf conf.IServerConf) (enable bool, err error) {
 	if err == conf.ErrNoSetting {
 		metric.Disable = true
 	} else {
-		if b, err := govalidator.ValidateStruct(&metric); !b {
+		if err := metric.Validate(); err != nil {
 			err = fmt.Errorf("metric配置有误:%v", err)
 			return false, err
 		}
@@ -33,6 +33,28 @@ func SetMetric(set ISetMetric, cnf conf.IServerConf) (enable bool, err error) {
 	return !metric.Disable && err == nil, err
 }
 
+//ISetTracing 设置链路跟踪
+type ISetTracing interface {
+	SetTracing(*conf.Tracing) error
+}
+
+//SetTracing 设置链路跟踪
+func SetTracing(set ISetTracing, cnf conf.IServerConf) (enable bool, err error) {
+	var tracing conf.Tracing
+	_, err = cnf.GetSubObject("tracing", &tracing)
+	if err != nil && err != conf.ErrNoSetting {
+		return false, err
+	}
+	if err == conf.ErrNoSetting {
+		tracing.Disable = true
+	} else if err := tracing.Validate(); err != nil {
+		err = fmt.Errorf("tracing配置有误:%v", err)
+		return false, err
+	}
+	err = set.SetTracing(&tracing)
+	return !tracing.Disable && err == nil, err
+}
+
 type ISetStatic interface {
 	SetStatic(static *conf.Static) error
 }
@@ -41,9 +41,6 @@ func (w *RpcResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if !comparer.IsChanged() {
 		return false, nil
 	}
-	if comparer.IsVarChanged() {
-		return true, nil
-	}
 	if comparer.IsValueChanged("status", "address", "host", "dn", "tls") {
 		return true, nil
 	}
@@ -54,7 +51,7 @@ func (w *RpcResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, error) {
 	if err != nil {
 		return ok, fmt.Errorf("路由未配置或配置有误:%s(%+v)", cnf.GetServerName(), err)
 	}
-	if ok := comparer.IsSubConfChanged("app"); ok {
+	if ok := comparer.IsSubConfChanged("app"); ok && !conf.IsAppWatched(cnf.GetServerType()) {
 		return ok, nil
 	}
 	return false, nil
@@ -90,6 +87,12 @@ func (w *RpcResponsiveServer) SetConf(restart bool, conf conf.IServerConf) (err
 	}
 	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "metric设置")
 
+	//设置链路跟踪
+	if ok, err = SetTracing(w.server, conf); err != nil {
+		return err
+	}
+	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "链路跟踪设置")
+
 	//设置host
 	if ok, err = SetHosts(w.server, conf); err != nil {
 		return err
@@ -11,6 +11,8 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/engines"
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 //RpcResponsiveServer rpc 响应式服务器
@@ -63,6 +65,8 @@ func NewRpcResponsiveServer(registryAddr string, cnf conf.IServerConf, logger *l
 
 //Restart 重启服务器
 func (w *RpcResponsiveServer) Restart(cnf conf.IServerConf) (err error) {
+	//重启期间保留监听端口,新连接在端口队列中等待
+	defer graceful.Hold()()
 	w.Shutdown()
 	time.Sleep(time.Second)
 	w.done = false
@@ -112,8 +116,7 @@ func (w *RpcResponsiveServer) Shutdown() {
 		close(w.closeChan)
 	})
 	w.unpublish()
-	timeout := w.currentConf.GetInt("timeout", 10)
-	w.server.Shutdown(time.Duration(timeout) * time.Second)
+	w.server.Shutdown(drain.GetTimeout(w.currentConf))
 	if w.engine != nil {
 		w.engine.Close()
 	}
@@ -129,6 +132,14 @@ func (w *RpcResponsiveServer) GetStatus() string {
 	return w.server.GetStatus()
 }
 
+//Ready 检查服务器是否就绪
+func (w *RpcResponsiveServer) Ready() error {
+	if w.done {
+		return servers.ErrServerClosed
+	}
+	return w.engine.CheckHealth()
+}
+
 //GetServices 获取服务列表
 func (w *RpcResponsiveServer) GetServices() map[string][]string {
 	svs := w.engine.GetServices()
@@ -12,7 +12,8 @@ import (
 
 func (s *RpcServer) getProcessor(routers []*conf.Router) (*Processor, error) {
 	engine := NewProcessor()
-	engine.Use(middleware.Logging(s.conf)) //记录请求日志
+	engine.Use(middleware.Drain(s.tracker)) //记录处理中的请求
+	engine.Use(middleware.Logging(s.conf))  //记录请求日志
 	engine.Use(middleware.Recovery())
 	engine.Use(s.option.metric.Handle())   //生成metric报表
 	engine.Use(middleware.Host(s.conf))    // 检查主机头是否合法
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/sereiner/parrot/component"
-	"net"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -17,6 +16,8 @@ import (
 
 	logger "github.com/sereiner/library/log"
 	"github.com/sereiner/parrot/conf"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 	"github.com/sereiner/parrot/servers/pkg/middleware"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -28,6 +29,7 @@ type RpcServer struct {
 	conf   *conf.MetadataConf
 	engine *grpc.Server
 	*Processor
+	tracker *drain.Tracker
 	running string
 	proto   string
 	port    string
@@ -38,7 +40,7 @@ type RpcServer struct {
 
 //NewRpcServer 创建rpc服务器
 func NewRpcServer(name string, address string, routers []*conf.Router, opts ...Option) (t *RpcServer, err error) {
-	t = &RpcServer{conf: &conf.MetadataConf{
+	t = &RpcServer{tracker: drain.NewTracker(), conf: &conf.MetadataConf{
 		Name: name,
 		Type: "rpc",
 	}, f: nil}
@@ -87,7 +89,7 @@ func (s *RpcServer) Run() error {
 	s.running = servers.ST_RUNNING
 	errChan := make(chan error, 1)
 	go func(ch chan error) {
-		lis, err := net.Listen("tcp", s.addr)
+		lis, err := graceful.Listen(s.addr)
 		if err != nil {
 			ch <- err
 			return
@@ -108,11 +110,23 @@ func (s *RpcServer) Run() error {
 
 //Shutdown 关闭服务器
 func (s *RpcServer) Shutdown(timeout time.Duration) {
+	defer s.StopTracing()
 	if s.engine != nil {
 		s.running = servers.ST_STOP
-		s.engine.GracefulStop()
-		time.Sleep(time.Second)
-
+		start := time.Now()
+		stopped := make(chan struct{})
+		go func() {
+			s.engine.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			s.engine.Stop()
+		}
+		if n := s.tracker.Wait(timeout - time.Since(start)); n > 0 {
+			s.Warnf("%s:%d个请求未在%v内处理完成", s.conf.Name, n, timeout)
+		}
 	}
 }
 
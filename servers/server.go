@@ -1,6 +1,7 @@
 package servers
 
 import (
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
 
@@ -23,6 +24,9 @@ var (
 	SRV_TP_WEB   = "web"
 )
 
+//ErrServerClosed 服务器已关闭
+var ErrServerClosed = errors.New("服务器已关闭")
+
 //IRegistryServer 基于注册中心的服务器
 type IRegistryServer interface {
 	Notify(conf.IServerConf) error
@@ -33,6 +37,7 @@ type IRegistryServer interface {
 	GetStatus() string
 	Shutdown()
 	SetPb(f func(component.IContainer, *grpc.Server))
+	Ready() error
 }
 
 type IExecuter interface {
@@ -54,6 +59,7 @@ type IRegistryEngine interface {
 	UpdateVarConf(conf conf.IServerConf)
 	GetServices() map[string][]string
 	Fallback(c *context.Context) (rs interface{})
+	CheckHealth() error
 }
 
 //IServerResolver 服务器生成器
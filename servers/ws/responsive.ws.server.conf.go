@@ -40,9 +40,6 @@ func (w *WSServerResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, erro
 	if !comparer.IsChanged() {
 		return false, nil
 	}
-	if comparer.IsVarChanged() {
-		return true, nil
-	}
 
 	if comparer.IsValueChanged("status", "address", "host", "rTimeout", "wTimeout", "rhTimeout") {
 		return true, nil
@@ -54,7 +51,7 @@ func (w *WSServerResponsiveServer) NeedRestart(cnf conf.IServerConf) (bool, erro
 	if err != nil {
 		return false, fmt.Errorf("路由未配置或配置有误:%v", err)
 	}
-	if ok := comparer.IsSubConfChanged("app"); ok {
+	if ok := comparer.IsSubConfChanged("app"); ok && !conf.IsAppWatched(cnf.GetServerType()) {
 		return ok, nil
 	}
 	if ok := comparer.IsSubConfChanged("circuit"); ok {
@@ -92,6 +89,12 @@ func (w *WSServerResponsiveServer) SetConf(restart bool, cnf conf.IServerConf) (
 	}
 	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "metric设置")
 
+	//设置链路跟踪
+	if ok, err = SetTracing(w.server, cnf); err != nil {
+		return err
+	}
+	servers.TraceIf(ok, w.Infof, w.Debugf, getEnableName(ok), "链路跟踪设置")
+
 	return nil
 }
 func getEnableName(b bool) string {
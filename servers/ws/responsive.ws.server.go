@@ -14,6 +14,8 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/engines"
 	"github.com/sereiner/parrot/servers"
+	"github.com/sereiner/parrot/servers/pkg/drain"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 type IServer interface {
@@ -27,6 +29,7 @@ type IServer interface {
 	SetRouters(routers []*conf.Router) (err error)
 	SetStatic(*conf.Static) error
 	SetMetric(*conf.Metric) error
+	SetTracing(*conf.Tracing) error
 	StopMetric() error
 }
 
@@ -80,6 +83,8 @@ func NewWSServerResponsiveServer(registryAddr string, cnf conf.IServerConf, logg
 
 //Restart 重启服务器
 func (w *WSServerResponsiveServer) Restart(cnf conf.IServerConf) (err error) {
+	//重启期间保留监听端口,新连接在端口队列中等待
+	defer graceful.Hold()()
 	w.Shutdown()
 	time.Sleep(time.Second)
 	w.done = false
@@ -133,7 +138,7 @@ func (w *WSServerResponsiveServer) Shutdown() {
 		close(w.closeChan)
 	})
 	w.unpublish()
-	w.server.Shutdown(10 * time.Second)
+	w.server.Shutdown(drain.GetTimeout(w.currentConf))
 	if w.engine != nil {
 		w.engine.Close()
 	}
@@ -149,6 +154,14 @@ func (w *WSServerResponsiveServer) GetStatus() string {
 	return w.server.GetStatus()
 }
 
+//Ready 检查服务器是否就绪
+func (w *WSServerResponsiveServer) Ready() error {
+	if w.done {
+		return servers.ErrServerClosed
+	}
+	return w.engine.CheckHealth()
+}
+
 //GetServices 获取服务列表
 func (w *WSServerResponsiveServer) GetServices() map[string][]string {
 	return w.engine.GetServices()
@@ -16,6 +16,7 @@ import (
 	"github.com/sereiner/parrot/conf"
 	"github.com/sereiner/parrot/servers"
 	"github.com/sereiner/parrot/servers/http/middleware"
+	"github.com/sereiner/parrot/servers/pkg/graceful"
 )
 
 //WSServer WSServer服务器
@@ -68,10 +69,15 @@ func NewWSServerServer(name string, addr string, routers []*conf.Router, opts ..
 // Run the http server
 func (s *WSServer) Run() error {
 	s.proto = "ws"
+	l, err := graceful.Listen(s.engine.Addr)
+	if err != nil {
+		s.running = servers.ST_STOP
+		return err
+	}
 	s.running = servers.ST_RUNNING
 	errChan := make(chan error, 1)
 	go func(ch chan error) {
-		if err := s.engine.ListenAndServe(); err != nil {
+		if err := s.engine.Serve(l); err != nil {
 			ch <- err
 		}
 	}(errChan)
@@ -86,6 +92,7 @@ func (s *WSServer) Run() error {
 
 //Shutdown 关闭服务器
 func (s *WSServer) Shutdown(timeout time.Duration) {
+	defer s.StopTracing()
 	if s.engine != nil {
 		s.metric.Stop()
 		s.running = servers.ST_STOP
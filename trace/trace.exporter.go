@@ -0,0 +1,224 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sereiner/parrot/conf"
+)
+
+//IExporter span导出程序
+type IExporter interface {
+	Export(spans []*SpanData) error
+	Close() error
+}
+
+//New 根据tracing配置构建tracer
+func New(service string, c *conf.Tracing, onError func(error)) (*Tracer, error) {
+	var exporter IExporter
+	var err error
+	switch c.Exporter {
+	case conf.TracingExporterOTLP:
+		exporter = NewOTLPExporter(c.GetEndpoint(), c.Headers)
+	case conf.TracingExporterFile:
+		exporter, err = NewFileExporter(c.GetPath())
+	default:
+		err = fmt.Errorf("不支持的exporter:%s", c.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewTracer(service, exporter, c.GetRatio(), onError), nil
+}
+
+//OTLPExporter 以OTLP/HTTP json格式推送到collector
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+//NewOTLPExporter 构建OTLP/HTTP导出程序,endpoint如 http://localhost:4318/v1/traces
+func NewOTLPExporter(endpoint string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		client:   &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+//Export 按服务分组推送span
+func (e *OTLPExporter) Export(spans []*SpanData) error {
+	buff, err := json.Marshal(toOTLP(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(buff))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送trace失败:%v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("推送trace失败:%d,%s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+//Close 关闭导出程序
+func (e *OTLPExporter) Close() error {
+	return nil
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string           `json:"traceId"`
+	SpanID            string           `json:"spanId"`
+	ParentSpanID      string           `json:"parentSpanId,omitempty"`
+	Name              string           `json:"name"`
+	Kind              SpanKind         `json:"kind"`
+	StartTimeUnixNano string           `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string           `json:"endTimeUnixNano"`
+	Attributes        []*otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus       `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope map[string]string `json:"scope"`
+	Spans []*otlpSpan       `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   map[string][]*otlpAttribute `json:"resource"`
+	ScopeSpans []*otlpScopeSpans           `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+func toOTLP(spans []*SpanData) *otlpRequest {
+	groups := make(map[string]*otlpScopeSpans)
+	req := &otlpRequest{ResourceSpans: make([]*otlpResourceSpans, 0, 1)}
+	for _, s := range spans {
+		scope, ok := groups[s.Service]
+		if !ok {
+			scope = &otlpScopeSpans{Scope: map[string]string{"name": "parrot"}, Spans: make([]*otlpSpan, 0, len(spans))}
+			groups[s.Service] = scope
+			req.ResourceSpans = append(req.ResourceSpans, &otlpResourceSpans{
+				Resource:   map[string][]*otlpAttribute{"attributes": {toAttribute("service.name", s.Service)}},
+				ScopeSpans: []*otlpScopeSpans{scope},
+			})
+		}
+		span := &otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        make([]*otlpAttribute, 0, len(s.Attributes)),
+		}
+		for k, v := range s.Attributes {
+			span.Attributes = append(span.Attributes, toAttribute(k, v))
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.Error}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+	return req
+}
+
+func toAttribute(k string, v interface{}) *otlpAttribute {
+	attr := &otlpAttribute{Key: k}
+	switch t := v.(type) {
+	case bool:
+		attr.Value.BoolValue = &t
+	case int:
+		s := strconv.Itoa(t)
+		attr.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(t, 10)
+		attr.Value.IntValue = &s
+	case float64:
+		attr.Value.DoubleValue = &t
+	default:
+		s := fmt.Sprint(v)
+		attr.Value.StringValue = &s
+	}
+	return attr
+}
+
+//FileExporter 以json行格式写入本地文件
+type FileExporter struct {
+	file *os.File
+	lock sync.Mutex
+}
+
+//NewFileExporter 构建文件导出程序,文件不存在时自动创建
+func NewFileExporter(path string) (*FileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建trace目录失败:%v", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开trace文件失败:%v", err)
+	}
+	return &FileExporter{file: f}, nil
+}
+
+//Export 每个span写入一行
+func (e *FileExporter) Export(spans []*SpanData) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	w := bufio.NewWriter(e.file)
+	encoder := json.NewEncoder(w)
+	for _, s := range spans {
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+//Close 关闭文件
+func (e *FileExporter) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.file.Close()
+}
@@ -0,0 +1,351 @@
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	mrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+//HeaderName W3C trace context传播使用的请求头
+const HeaderName = "traceparent"
+
+//SpanKind span类型,取值与OTLP一致
+type SpanKind int
+
+const (
+	//KindInternal 内部操作
+	KindInternal SpanKind = 1
+	//KindServer 处理外部请求
+	KindServer SpanKind = 2
+	//KindClient 发起远程调用
+	KindClient SpanKind = 3
+	//KindProducer 发送消息
+	KindProducer SpanKind = 4
+	//KindConsumer 消费消息
+	KindConsumer SpanKind = 5
+)
+
+//SpanContext span标识,可通过traceparent在进程间传递
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+//IsValid trace id与span id均不为0
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != [16]byte{} && s.SpanID != [8]byte{}
+}
+
+//String 转换为traceparent格式:00-{trace-id}-{span-id}-{flags}
+func (s SpanContext) String() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(s.TraceID[:]), hex.EncodeToString(s.SpanID[:]), flags)
+}
+
+//ParseTraceParent 解析traceparent,格式有误时返回false
+func ParseTraceParent(v string) (s SpanContext, ok bool) {
+	sections := strings.Split(strings.TrimSpace(v), "-")
+	if len(sections) < 4 || len(sections[0]) != 2 || sections[0] == "ff" ||
+		len(sections[1]) != 32 || len(sections[2]) != 16 || len(sections[3]) != 2 {
+		return s, false
+	}
+	if sections[0] == "00" && len(sections) != 4 {
+		return s, false
+	}
+	if _, err := hex.Decode(s.TraceID[:], []byte(sections[1])); err != nil {
+		return s, false
+	}
+	if _, err := hex.Decode(s.SpanID[:], []byte(sections[2])); err != nil {
+		return s, false
+	}
+	flags, err := hex.DecodeString(sections[3])
+	if err != nil {
+		return s, false
+	}
+	s.Sampled = flags[0]&1 == 1
+	return s, s.IsValid()
+}
+
+//Span 一次操作的跟踪记录,所有方法均可在nil上调用,未启用跟踪时不做任何处理
+type Span struct {
+	tracer *Tracer
+	ctx    SpanContext
+	parent [8]byte
+	name   string
+	kind   SpanKind
+	start  time.Time
+	attrs  map[string]interface{}
+	err    string
+	ended  bool
+	lock   sync.Mutex
+}
+
+//Child 创建子span
+func (s *Span) Child(name string, kind SpanKind) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.newSpan(name, kind, s.ctx, true)
+}
+
+//SetAttribute 设置属性
+func (s *Span) SetAttribute(key string, value interface{}) *Span {
+	if s == nil {
+		return s
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.ended {
+		s.attrs[key] = value
+	}
+	return s
+}
+
+//SetError 记录错误,err为nil时忽略
+func (s *Span) SetError(err error) *Span {
+	if s == nil || err == nil {
+		return s
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err.Error()
+	return s
+}
+
+//End 结束span,已采样的span交由导出程序输出
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	data := &SpanData{
+		TraceID:    hex.EncodeToString(s.ctx.TraceID[:]),
+		SpanID:     hex.EncodeToString(s.ctx.SpanID[:]),
+		Service:    s.tracer.service,
+		Name:       s.name,
+		Kind:       s.kind,
+		Start:      s.start,
+		End:        time.Now(),
+		Attributes: s.attrs,
+		Error:      s.err,
+	}
+	if s.parent != [8]byte{} {
+		data.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	s.lock.Unlock()
+	if s.ctx.Sampled {
+		s.tracer.export(data)
+	}
+}
+
+//TraceParent 获取用于传递给下游的traceparent
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return s.ctx.String()
+}
+
+//TraceID 获取trace id
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.ctx.TraceID[:])
+}
+
+//SpanData 已结束的span
+type SpanData struct {
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Service      string                 `json:"service"`
+	Name         string                 `json:"name"`
+	Kind         SpanKind               `json:"kind"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+//Tracer 创建span并批量导出,所有方法均可在nil上调用
+type Tracer struct {
+	service   string
+	exporter  IExporter
+	ratio     float64
+	spans     chan *SpanData
+	closeChan chan struct{}
+	done      chan struct{}
+	once      sync.Once
+	onError   func(error)
+}
+
+//batchSize 单次导出的最大span数
+var batchSize = 512
+
+//flushInterval 导出间隔
+var flushInterval = time.Second * 5
+
+//NewTracer 构建tracer,ratio为新建trace的采样比例,上游已传递traceparent时沿用上游的采样标识
+func NewTracer(service string, exporter IExporter, ratio float64, onError func(error)) *Tracer {
+	t := &Tracer{
+		service:   service,
+		exporter:  exporter,
+		ratio:     ratio,
+		spans:     make(chan *SpanData, batchSize*4),
+		closeChan: make(chan struct{}),
+		done:      make(chan struct{}),
+		onError:   onError,
+	}
+	go t.loop()
+	return t
+}
+
+//Start 创建根span,traceparent为上游传递的值,为空或格式错误时创建新的trace
+func (t *Tracer) Start(name string, kind SpanKind, traceparent string) *Span {
+	if t == nil {
+		return nil
+	}
+	if parent, ok := ParseTraceParent(traceparent); ok {
+		return t.newSpan(name, kind, parent, true)
+	}
+	parent := SpanContext{Sampled: t.ratio >= 1 || mrand.Float64() < t.ratio}
+	rand.Read(parent.TraceID[:])
+	return t.newSpan(name, kind, parent, false)
+}
+
+func (t *Tracer) newSpan(name string, kind SpanKind, parent SpanContext, hasParent bool) *Span {
+	s := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+		ctx:    SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled},
+	}
+	if hasParent {
+		s.parent = parent.SpanID
+	}
+	rand.Read(s.ctx.SpanID[:])
+	return s
+}
+
+//export 导出队列已满时丢弃
+func (t *Tracer) export(s *SpanData) {
+	select {
+	case t.spans <- s:
+	default:
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.done)
+	batch := make([]*SpanData, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.Export(batch); err != nil && t.onError != nil {
+			t.onError(err)
+		}
+		batch = make([]*SpanData, 0, batchSize)
+	}
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case s := <-t.spans:
+			if batch = append(batch, s); len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.closeChan:
+			for {
+				select {
+				case s := <-t.spans:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+//Close 导出剩余的span并关闭导出程序
+func (t *Tracer) Close() (err error) {
+	if t == nil {
+		return nil
+	}
+	t.once.Do(func() {
+		close(t.closeChan)
+		<-t.done
+		err = t.exporter.Close()
+	})
+	return err
+}
+
+//MessageKey 消息中保存traceparent的字段名
+const MessageKey = "__traceparent_"
+
+//InjectMessage 将traceparent写入json对象格式的消息,其它格式的消息原样返回
+func InjectMessage(msg string, traceparent string) string {
+	v := strings.TrimSpace(msg)
+	if traceparent == "" || !strings.HasPrefix(v, "{") || !json.Valid([]byte(v)) {
+		return msg
+	}
+	field := fmt.Sprintf(`"%s":"%s"`, MessageKey, traceparent)
+	body := strings.TrimSpace(v[1:])
+	if body == "}" {
+		return "{" + field + "}"
+	}
+	return "{" + field + "," + body
+}
+
+//StripMessage 移除InjectMessage写入的traceparent,返回原始消息与traceparent;
+//消息中无traceparent时原样返回
+func StripMessage(msg string) (string, string) {
+	prefix := fmt.Sprintf(`{"%s":"`, MessageKey)
+	if !strings.HasPrefix(msg, prefix) {
+		return msg, ""
+	}
+	end := strings.Index(msg[len(prefix):], `"`)
+	if end < 0 {
+		return msg, ""
+	}
+	traceparent := msg[len(prefix) : len(prefix)+end]
+	if _, ok := ParseTraceParent(traceparent); !ok {
+		return msg, ""
+	}
+	switch rest := msg[len(prefix)+end+1:]; {
+	case rest == "}":
+		return "{}", traceparent
+	case strings.HasPrefix(rest, ","):
+		return "{" + rest[1:], traceparent
+	}
+	return msg, ""
+}
+
+//ExtractMessage 获取消息中的traceparent
+func ExtractMessage(form map[string]interface{}) string {
+	if v, ok := form[MessageKey].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sereiner/library/ut"
+	"github.com/sereiner/parrot/conf"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	v := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	s, ok := ParseTraceParent(v)
+	ut.Expect(t, ok, true)
+	ut.Expect(t, s.Sampled, true)
+	ut.Expect(t, s.String(), v)
+
+	_, ok = ParseTraceParent("00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	ut.Expect(t, ok, false)
+	_, ok = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7")
+	ut.Expect(t, ok, false)
+	_, ok = ParseTraceParent("")
+	ut.Expect(t, ok, false)
+}
+
+func TestInjectMessage(t *testing.T) {
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ut.Expect(t, InjectMessage(`{"id":1}`, tp), `{"__traceparent_":"`+tp+`","id":1}`)
+	ut.Expect(t, InjectMessage(`{}`, tp), `{"__traceparent_":"`+tp+`"}`)
+	ut.Expect(t, InjectMessage(`[1,2]`, tp), `[1,2]`)
+	ut.Expect(t, InjectMessage(`{"id":`, tp), `{"id":`)
+
+	form := map[string]interface{}{}
+	json.Unmarshal([]byte(InjectMessage(`{"id":1}`, tp)), &form)
+	ut.Expect(t, ExtractMessage(form), tp)
+
+	raw, v := StripMessage(InjectMessage(`{"id":1}`, tp))
+	ut.Expect(t, raw, `{"id":1}`)
+	ut.Expect(t, v, tp)
+	raw, v = StripMessage(InjectMessage(`{}`, tp))
+	ut.Expect(t, raw, `{}`)
+	ut.Expect(t, v, tp)
+	raw, v = StripMessage(`{"__traceparent_":"x","id":1}`)
+	ut.Expect(t, raw, `{"__traceparent_":"x","id":1}`)
+	ut.Expect(t, v, "")
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "parrot_trace_test", "trace.json")
+	os.RemoveAll(filepath.Dir(path))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tracer, err := New("order", conf.NewFileTracing(path), nil)
+	ut.Expect(t, err, nil)
+	root := tracer.Start("api /order/pay", KindServer, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	child := root.Child("db.query", KindClient).SetAttribute("db.name", "db")
+	child.SetError(errors.New("timeout"))
+	child.End()
+	root.End()
+	root.End()
+	ut.Expect(t, tracer.Close(), nil)
+
+	f, err := os.Open(path)
+	ut.Expect(t, err, nil)
+	defer f.Close()
+	spans := make([]*SpanData, 0, 2)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s SpanData
+		ut.Expect(t, json.Unmarshal(scanner.Bytes(), &s), nil)
+		spans = append(spans, &s)
+	}
+	ut.Expect(t, len(spans), 2)
+	ut.Expect(t, spans[0].Name, "db.query")
+	ut.Expect(t, spans[0].Error, "timeout")
+	ut.Expect(t, spans[0].ParentSpanID, spans[1].SpanID)
+	ut.Expect(t, spans[1].TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	ut.Expect(t, spans[1].ParentSpanID, "00f067aa0ba902b7")
+	ut.Expect(t, spans[1].Service, "order")
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buff, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(buff, &body)
+		ut.Expect(t, r.Header.Get("Content-Type"), "application/json")
+		ut.Expect(t, r.Header.Get("x-token"), "123")
+	}))
+	defer server.Close()
+
+	c := conf.NewOTLPTracing(server.URL)
+	c.Headers = map[string]string{"x-token": "123"}
+	tracer, err := New("order", c, nil)
+	ut.Expect(t, err, nil)
+	tracer.Start("rpc /order/query", KindServer, "").SetAttribute("status", 200).End()
+	ut.Expect(t, tracer.Close(), nil)
+
+	rs := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	attr := rs["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	ut.Expect(t, attr["key"], "service.name")
+	span := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+	ut.Expect(t, span["name"], "rpc /order/query")
+	ut.Expect(t, span["kind"], float64(KindServer))
+	ut.Expect(t, len(span["traceId"].(string)), 32)
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("api /order", KindServer, "")
+	ut.Expect(t, span == nil, true)
+	span.Child("db.query", KindClient).SetAttribute("k", "v").SetError(errors.New("err")).End()
+	ut.Expect(t, span.TraceParent(), "")
+	ut.Expect(t, tracer.Close(), nil)
+}